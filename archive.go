@@ -0,0 +1,127 @@
+package doublestar
+
+import (
+	"io/fs"
+
+	"github.com/bmatcuk/doublestar/v4/archivefs"
+)
+
+// ArchiveSeparator separates the path of an archive from a path inside of it,
+// both in patterns passed to ArchiveGlob() and in the paths it returns.
+const ArchiveSeparator = "!/"
+
+// ArchiveGlob is like Glob(), except that the pattern may traverse into zip
+// and tar archives (including jars, wheels, gzipped tars, and so on) using
+// ArchiveSeparator. For example, `libs/*.jar!/**/*.class` finds every class
+// file in every jar in `libs`, and archives may be nested, as in
+// `dist/*.tar.gz!/**/*.jar!/META-INF/MANIFEST.MF`. Returned paths use the same
+// notation, such as `libs/a.jar!/com/example/A.class`.
+//
+// Archives are detected by their contents, not their names, and are read into
+// memory in their entirety. See the archivefs package for details. A path
+// that matches the part of the pattern before an ArchiveSeparator, but which
+// isn't an archive, is treated like an I/O error: it is ignored by default,
+// but WithFailOnIOErrors, WithErrorHandler, and WithCollectIOErrors work as
+// usual, with an Op of "open". Paths reported to an error handler, or recorded
+// in a GlobIOError, are also written using ArchiveSeparator.
+//
+// Results are returned in the same order that ArchiveGlobWalk() would walk
+// them, which may not be sorted. To match a literal `!/` in a path, escape
+// the `!`, as in `a\!/b`. An ArchiveSeparator may not appear inside of an
+// alternation (`{...}`). The only possible returned error is ErrBadPattern,
+// reporting that the pattern is malformed, unless an option that returns I/O
+// errors was passed.
+//
+func ArchiveGlob(fsys fs.FS, pattern string, opts ...GlobOption) (matches []string, err error) {
+	err = ArchiveGlobWalk(fsys, pattern, func(p string, d fs.DirEntry) error {
+		matches = append(matches, p)
+		return nil
+	}, opts...)
+	if err != nil {
+		if _, ok := err.(joinedIOErrors); !ok {
+			return nil, err
+		}
+	}
+	return
+}
+
+// ArchiveGlobWalk is the GlobWalk() equivalent of ArchiveGlob(): it calls the
+// callback function `fn` for every file matching pattern, which may traverse
+// into archives. The path passed to `fn` is written the same way as the
+// results of ArchiveGlob(). Otherwise, the behavior is the same as GlobWalk(),
+// including support for returning SkipDir from `fn`.
+//
+func ArchiveGlobWalk(fsys fs.FS, pattern string, fn GlobWalkFunc, opts ...GlobOption) error {
+	parts := splitArchivePattern(pattern)
+	for _, p := range parts {
+		if !ValidatePattern(p) {
+			return ErrBadPattern
+		}
+	}
+
+	g := newGlob(opts...)
+	defer g.startStats()()
+
+	err := g.archiveGlobWalk(fsys, parts, "", g.limitWalkFunc(g.statsWalkFunc(fn)))
+	if err == errLimitReached {
+		err = nil
+	}
+	return g.collectedIOErrors(err)
+}
+
+// Globs `parts[0]` in `fsys`, which is the archive `prefix` (or the root if
+// `prefix` is empty), opening each match as an archive and globbing the rest
+// of the parts inside of it.
+func (g *glob) archiveGlobWalk(fsys fs.FS, parts []string, prefix string, fn GlobWalkFunc) error {
+	g.archivePrefix = prefix
+	if len(parts) == 1 {
+		return g.doGlobWalk(fsys, parts[0], true, func(p string, d fs.DirEntry) error {
+			return fn(prefix+p, d)
+		})
+	}
+
+	return g.doGlobWalk(fsys, parts[0], true, func(p string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+
+		archive, err := archivefs.Open(fsys, p)
+		if err != nil {
+			return g.forwardIOError("open", p, err)
+		}
+
+		err = g.archiveGlobWalk(archive, parts[1:], prefix+p+ArchiveSeparator, fn)
+		g.archivePrefix = prefix
+		return err
+	})
+}
+
+// Splits a pattern on each unescaped ArchiveSeparator that isn't inside of a
+// character class.
+func splitArchivePattern(p string) (parts []string) {
+	start := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			// skip next byte
+			i++
+
+		case '[':
+			j := i + 1
+			if j < len(p) && (p[j] == '^' || p[j] == '!') {
+				j++
+			}
+			if closing := indexUnescapedByte(p[j:], ']', true); closing != -1 {
+				i = j + closing
+			}
+
+		case '!':
+			if i+1 < len(p) && p[i+1] == '/' {
+				parts = append(parts, p[start:i])
+				start = i + 2
+				i++
+			}
+		}
+	}
+	return append(parts, p[start:])
+}
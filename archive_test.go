@@ -0,0 +1,148 @@
+package doublestar
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4/archivefs"
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+func TestSplitArchivePattern(t *testing.T) {
+	tests := map[string][]string{
+		"a/b":                 {"a/b"},
+		"a.jar!/b":            {"a.jar", "b"},
+		"a.tgz!/b.jar!/**":    {"a.tgz", "b.jar", "**"},
+		"a!b":                 {"a!b"},
+		"a\\!/b":              {"a\\!/b"},
+		"a[!/]b!/c":           {"a[!/]b", "c"},
+		"a.jar!/":             {"a.jar", ""},
+		"!/a":                 {"", "a"},
+		"[!/x":                {"[", "x"},
+		"{a,b}.jar!/{c,d!/e}": {"{a,b}.jar", "{c,d", "e}"},
+	}
+	for pattern, expected := range tests {
+		if parts := splitArchivePattern(pattern); !reflect.DeepEqual(parts, expected) {
+			t.Errorf("splitArchivePattern(%#q) = %#v - should be %#v", pattern, parts, expected)
+		}
+	}
+}
+
+func makeTestZip(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func makeTestTarGz(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(data)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func newArchiveTestFS(t *testing.T) fs.FS {
+	return memfs.New().
+		File("libs/a.jar", makeTestZip(t, "com/A.class", "com/B.txt", "META-INF/MANIFEST.MF")).
+		File("libs/b.jar", makeTestZip(t, "com/x/C.class")).
+		File("libs/notes.jar", []byte("not a jar")).
+		File("dist/app.tar.gz", makeTestTarGz(t, map[string][]byte{
+			"lib/inner.jar": makeTestZip(t, "X.class"),
+			"lib/bad.jar":   []byte("not a jar"),
+			"README":        []byte("hello"),
+		}))
+}
+
+func TestArchiveGlob(t *testing.T) {
+	fsys := newArchiveTestFS(t)
+	tests := map[string][]string{
+		"libs/*.jar!/**/*.class":              {"libs/a.jar!/com/A.class", "libs/b.jar!/com/x/C.class"},
+		"dist/*.tar.gz!/**/*.jar!/**/*.class": {"dist/app.tar.gz!/lib/inner.jar!/X.class"},
+		"**/*.jar!/META-INF/MANIFEST.MF":      {"libs/a.jar!/META-INF/MANIFEST.MF"},
+		"dist/app.tar.gz!/*":                  {"dist/app.tar.gz!/README", "dist/app.tar.gz!/lib"},
+		"libs/{a,b}.jar":                      {"libs/a.jar", "libs/b.jar"},
+		"libs!/*":                             nil,
+	}
+	for pattern, expected := range tests {
+		matches, err := ArchiveGlob(fsys, pattern)
+		if err != nil || !reflect.DeepEqual(matches, expected) {
+			t.Errorf("ArchiveGlob(%#q) = %#v, %v - should be %#v", pattern, matches, err, expected)
+		}
+	}
+
+	if _, err := ArchiveGlob(fsys, "libs/*.jar!/["); err != ErrBadPattern {
+		t.Errorf("ArchiveGlob(`libs/*.jar!/[`) has error %v - should be ErrBadPattern", err)
+	}
+
+	matches, err := ArchiveGlob(fsys, "**/*.jar!/**/*.class", WithLimit(1))
+	if err != nil || len(matches) != 1 {
+		t.Errorf("ArchiveGlob(`**/*.jar!/**/*.class`, WithLimit(1)) = %#v, %v - should have 1 result", matches, err)
+	}
+
+	var walked []string
+	err = ArchiveGlobWalk(fsys, "libs/*.jar!/com/*", func(p string, d fs.DirEntry) error {
+		walked = append(walked, p)
+		if d.IsDir() {
+			return SkipDir
+		}
+		return nil
+	})
+	expected := []string{"libs/a.jar!/com/A.class", "libs/a.jar!/com/B.txt", "libs/b.jar!/com/x"}
+	if err != nil || !reflect.DeepEqual(walked, expected) {
+		t.Errorf("ArchiveGlobWalk(`libs/*.jar!/com/*`) = %#v, %v - should be %#v", walked, err, expected)
+	}
+}
+
+func TestArchiveGlobIOErrors(t *testing.T) {
+	fsys := newArchiveTestFS(t)
+
+	_, err := ArchiveGlob(fsys, "libs/*.jar!/**", WithFailOnIOErrors())
+	if !errors.Is(err, archivefs.ErrUnknownFormat) {
+		t.Errorf("ArchiveGlob(`libs/*.jar!/**`, WithFailOnIOErrors) has error %v - should be ErrUnknownFormat", err)
+	}
+
+	matches, err := ArchiveGlob(fsys, "**/*.jar!/**/*.class", WithCollectIOErrors())
+	var paths []string
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if ioErr, ok := e.(*GlobIOError); ok && ioErr.Op == "open" {
+				paths = append(paths, ioErr.Path)
+			}
+		}
+	}
+	if len(matches) != 2 || !reflect.DeepEqual(paths, []string{"libs/notes.jar"}) {
+		t.Errorf("ArchiveGlob(`**/*.jar!/**/*.class`, WithCollectIOErrors) = %#v, %v - should have 2 results and an error for libs/notes.jar", matches, err)
+	}
+
+	// errors inside of archives have the archive's path
+	var handled []string
+	ArchiveGlob(fsys, "dist/*.tar.gz!/lib/*.jar!/**", WithErrorHandler(func(p string, err error) error {
+		handled = append(handled, p)
+		return nil
+	}))
+	if !reflect.DeepEqual(handled, []string{"dist/app.tar.gz!/lib/bad.jar"}) {
+		t.Errorf("ArchiveGlob(`dist/*.tar.gz!/lib/*.jar!/**`, WithErrorHandler) handled %#v - should be dist/app.tar.gz!/lib/bad.jar", handled)
+	}
+}
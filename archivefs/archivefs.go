@@ -0,0 +1,175 @@
+// Package archivefs exposes zip and tar archives as an fs.FS, so that they can
+// be globbed with doublestar. See also doublestar.ArchiveGlob(), which can
+// glob into archives nested inside of other archives.
+//
+// Archives are read into memory in their entirety, so this package is not a
+// good fit for archives that are larger than the available memory.
+package archivefs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+// ErrUnknownFormat is returned by Open() when a file is not a zip, tar, or
+// gzipped tar archive.
+var ErrUnknownFormat = errors.New("unknown archive format")
+
+// NewTar reads a tar archive, which may be gzipped, from `r` and indexes it as
+// an in-memory fs.FS. Regular files, directories, symlinks, and hard links are
+// supported; other kinds of entries, and entries with names that aren't valid
+// paths (see fs.ValidPath()) after removing any leading `/` or `./`, are
+// skipped. Like extracting an archive, if several entries have the same name,
+// the last one wins.
+func NewTar(r io.Reader) (*memfs.FS, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	fsys := memfs.New()
+	b := &tarBuilder{fsys: fsys, files: make(map[string][]byte), nonDirs: make(map[string]bool)}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fsys, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = b.add(hdr, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type tarBuilder struct {
+	fsys *memfs.FS
+
+	// the contents of every regular file, for hard links
+	files map[string][]byte
+
+	// every file and symlink, which need to be removed if a later entry uses
+	// them as a directory
+	nonDirs map[string]bool
+}
+
+func (b *tarBuilder) add(hdr *tar.Header, r io.Reader) error {
+	name := cleanTarName(hdr.Name)
+	if name == "." || !fs.ValidPath(name) {
+		return nil
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		b.makeRoom(name, true)
+		b.fsys.Dir(name)
+
+	case tar.TypeReg, tar.TypeRegA:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		b.makeRoom(name, false)
+		b.fsys.File(name, data)
+		b.files[name] = data
+		b.nonDirs[name] = true
+
+	case tar.TypeLink:
+		data, ok := b.files[cleanTarName(hdr.Linkname)]
+		if !ok {
+			return nil
+		}
+		b.makeRoom(name, false)
+		b.fsys.File(name, data)
+		b.files[name] = data
+		b.nonDirs[name] = true
+
+	case tar.TypeSymlink:
+		b.makeRoom(name, false)
+		b.fsys.Symlink(name, hdr.Linkname)
+		b.nonDirs[name] = true
+
+	default:
+		return nil
+	}
+
+	b.fsys.Touch(name, hdr.ModTime)
+	return nil
+}
+
+// Removes any leading `/`, and cleans up things like `./` and `a/../`.
+func cleanTarName(name string) string {
+	return path.Clean(strings.TrimLeft(name, "/"))
+}
+
+// Removes any files or symlinks that are in the way of `name`: its parents,
+// and, if it is a directory, `name` itself.
+func (b *tarBuilder) makeRoom(name string, isDir bool) {
+	for i := 0; i < len(name); i++ {
+		if name[i] == '/' && b.nonDirs[name[:i]] {
+			b.remove(name[:i])
+		}
+	}
+	if isDir && b.nonDirs[name] {
+		b.remove(name)
+	}
+}
+
+func (b *tarBuilder) remove(name string) {
+	b.fsys.Remove(name)
+	delete(b.nonDirs, name)
+	delete(b.files, name)
+}
+
+// Open opens the archive `name` in `fsys` as an fs.FS. The format is detected
+// from the contents of the file, not its name, so, for example, jars, wheels,
+// and other zip-based formats are supported. Supported formats are zip, tar,
+// and gzipped tar. If the file isn't any of those, the returned error wraps
+// ErrUnknownFormat.
+func Open(fsys fs.FS, name string) (fs.FS, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive fs.FS
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) || bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		archive, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}) || isTar(data):
+		archive, err = NewTar(bytes.NewReader(data))
+
+	default:
+		err = ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return archive, nil
+}
+
+// Returns true if `data` starts with a tar header.
+func isTar(data []byte) bool {
+	// ustar (POSIX) and GNU tar headers have a magic string at offset 257
+	return len(data) >= 262 && string(data[257:262]) == "ustar"
+}
@@ -0,0 +1,147 @@
+package archivefs_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/bmatcuk/doublestar/v4/archivefs"
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+var modTime = time.Unix(1600000000, 0)
+
+func makeTar(t *testing.T, gzipped bool, headers ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+
+	for _, hdr := range headers {
+		data := []byte(hdr.Name)
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(data))
+		}
+		if hdr.ModTime.IsZero() {
+			hdr.ModTime = modTime
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			tw.Write(data)
+		}
+	}
+	tw.Close()
+	if gz != nil {
+		gz.Close()
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(name))
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func reg(name string) *tar.Header { return &tar.Header{Typeflag: tar.TypeReg, Name: name} }
+func dir(name string) *tar.Header { return &tar.Header{Typeflag: tar.TypeDir, Name: name} }
+
+func TestNewTar(t *testing.T) {
+	headers := []*tar.Header{
+		dir("./pkg/"),
+		reg("./pkg/a.py"),
+		reg("pkg/sub/b.py"),
+		{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "pkg/sub"},
+		{Typeflag: tar.TypeLink, Name: "hard.py", Linkname: "./pkg/a.py"},
+		reg("/abs.txt"),
+		reg("../escape.txt"),
+		{Typeflag: tar.TypeFifo, Name: "fifo"},
+
+		// later entries win, even if they need a file to become a directory
+		reg("x"),
+		reg("x/y"),
+	}
+
+	for _, gzipped := range []bool{false, true} {
+		fsys, err := archivefs.NewTar(bytes.NewReader(makeTar(t, gzipped, headers...)))
+		if err != nil {
+			t.Errorf("NewTar(gzipped=%v) has error %v", gzipped, err)
+			continue
+		}
+
+		if err := fstest.TestFS(fsys, "pkg/a.py", "pkg/sub/b.py", "link", "hard.py", "abs.txt", "x/y"); err != nil {
+			t.Errorf("NewTar(gzipped=%v): %v", gzipped, err)
+		}
+
+		matches, _ := doublestar.Glob(fsys, "**/*.py")
+		expected := []string{"hard.py", "link/b.py", "pkg/a.py", "pkg/sub/b.py"}
+		if !reflect.DeepEqual(matches, expected) {
+			t.Errorf("NewTar(gzipped=%v): Glob(`**/*.py`) = %#v - should be %#v", gzipped, matches, expected)
+		}
+
+		if data, err := fs.ReadFile(fsys, "hard.py"); err != nil || string(data) != "./pkg/a.py" {
+			t.Errorf("NewTar(gzipped=%v): ReadFile(`hard.py`) = %#q, %v - should be the contents of pkg/a.py", gzipped, data, err)
+		}
+		if info, err := fsys.Stat("pkg/a.py"); err != nil || !info.ModTime().Equal(modTime) {
+			t.Errorf("NewTar(gzipped=%v): Stat(`pkg/a.py`) = %v, %v - should have the modification time from the archive", gzipped, info, err)
+		}
+		for _, name := range []string{"fifo", "escape.txt"} {
+			if _, err := fsys.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("NewTar(gzipped=%v): Stat(%#q) has error %v - should be skipped", gzipped, name, err)
+			}
+		}
+	}
+
+	if _, err := archivefs.NewTar(bytes.NewReader([]byte("not a tar"))); err == nil {
+		t.Errorf("NewTar(`not a tar`) should have an error")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	fsys := memfs.New().
+		File("a.jar", makeZip(t, "com/A.class")).
+		File("b.tar", makeTar(t, false, reg("com/B.class"))).
+		File("c.tgz", makeTar(t, true, reg("com/C.class"))).
+		File("d.txt", []byte("hello"))
+
+	for _, name := range []string{"a.jar", "b.tar", "c.tgz"} {
+		archive, err := archivefs.Open(fsys, name)
+		if err != nil {
+			t.Errorf("Open(%#q) has error %v", name, err)
+			continue
+		}
+		if matches, _ := doublestar.Glob(archive, "**/*.class"); len(matches) != 1 {
+			t.Errorf("Open(%#q): Glob(`**/*.class`) = %#v - should have 1 result", name, matches)
+		}
+	}
+
+	if _, err := archivefs.Open(fsys, "d.txt"); !errors.Is(err, archivefs.ErrUnknownFormat) {
+		t.Errorf("Open(`d.txt`) has error %v - should be ErrUnknownFormat", err)
+	}
+	if _, err := archivefs.Open(fsys, "missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(`missing`) has error %v - should be fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,494 @@
+package doublestar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+// An automaton is a nondeterministic finite automaton that accepts exactly
+// the names that Match() would match with a pattern, including Match()'s
+// corner cases: for example, `**` is only a doublestar if it is the only
+// thing in its path segment, and, when Match() reaches the end of the name,
+// the rest of the pattern only matches if it is exactly `*`, `**`, or `/**`.
+//
+// The pattern is compiled into a graph of nodes, where each node matches a
+// single rune (or is a star, an alternation, or the end of the pattern) and
+// points to the next node. The states of the automaton are positions in that
+// graph, plus whatever else Match() keeps track of at that position.
+type automaton struct {
+	nodes  []automatonNode
+	start  int
+	states []automatonState
+	ids    map[automatonState]int
+	zero   map[int]bool
+	alts   map[int][]int
+}
+
+type automatonNodeKind uint8
+
+const (
+	anEnd automatonNodeKind = iota
+	anRune
+	anSeparator
+	anStar
+	anAny
+	anClass
+	anAlt
+)
+
+type automatonNode struct {
+	kind    automatonNodeKind
+	r       rune           // for anRune
+	negated bool           // for anClass
+	ranges  []syntax.Range // for anClass
+	alts    []int          // for anAlt: the first node of each alternative
+	next    int
+}
+
+type automatonStateKind uint8
+
+const (
+	// at a node; sos is true if the node starts a path segment
+	asNode automatonStateKind = iota
+
+	// a `*` has matched at least one rune, and may match more; node is the
+	// node after the `*`
+	asStar
+
+	// a `**/` is skipping directories; node is the node after the `**/`
+	asDoubleStar
+
+	// a `**` at the end of the pattern has matched, so anything else matches
+	asAcceptAll
+)
+
+type automatonState struct {
+	kind automatonStateKind
+	node int
+	sos  bool
+}
+
+// Compiles a pattern into an automaton. The only possible returned error is
+// ErrBadPattern.
+func newAutomaton(pattern string) (*automaton, error) {
+	root, err := syntax.Parse(pattern)
+	if err != nil {
+		return nil, ErrBadPattern
+	}
+
+	a := &automaton{
+		ids:  make(map[automatonState]int),
+		zero: make(map[int]bool),
+		alts: make(map[int][]int),
+	}
+	end := a.add(automatonNode{kind: anEnd})
+	a.start = a.compile(root, end)
+	return a, nil
+}
+
+func (a *automaton) add(n automatonNode) int {
+	a.nodes = append(a.nodes, n)
+	return len(a.nodes) - 1
+}
+
+// Compiles a Sequence, which is followed by `next`, returning the first node.
+func (a *automaton) compile(seq *syntax.Node, next int) int {
+	for i := len(seq.Children) - 1; i >= 0; i-- {
+		n := seq.Children[i]
+		switch n.Kind {
+		case syntax.Literal:
+			runes := []rune(n.Text)
+			for j := len(runes) - 1; j >= 0; j-- {
+				next = a.add(automatonNode{kind: anRune, r: runes[j], next: next})
+			}
+
+		case syntax.Separator:
+			next = a.add(automatonNode{kind: anSeparator, next: next})
+
+		case syntax.Star:
+			next = a.add(automatonNode{kind: anStar, next: next})
+
+		case syntax.DoubleStar:
+			// Match() decides if `**` is a doublestar as it reads the pattern, so
+			// it is compiled as two stars and the decision is made in step()
+			next = a.add(automatonNode{kind: anStar, next: next})
+			next = a.add(automatonNode{kind: anStar, next: next})
+
+		case syntax.Any:
+			next = a.add(automatonNode{kind: anAny, next: next})
+
+		case syntax.Class:
+			next = a.add(automatonNode{kind: anClass, negated: n.Negated, ranges: n.Ranges, next: next})
+
+		case syntax.Alternation:
+			alts := make([]int, len(n.Children))
+			for j, alt := range n.Children {
+				alts[j] = a.compile(alt, next)
+			}
+			next = a.add(automatonNode{kind: anAlt, alts: alts})
+		}
+	}
+	return next
+}
+
+// Returns the id of a state.
+func (a *automaton) id(s automatonState) int {
+	if id, ok := a.ids[s]; ok {
+		return id
+	}
+	a.states = append(a.states, s)
+	a.ids[s] = len(a.states) - 1
+	return len(a.states) - 1
+}
+
+// Returns the set of states the automaton starts in.
+func (a *automaton) startSet() []int {
+	return []int{a.id(automatonState{kind: asNode, node: a.start, sos: true})}
+}
+
+// Returns true if any of the states in the set accept.
+func (a *automaton) accepts(set []int) bool {
+	for _, id := range set {
+		s := a.states[id]
+		switch s.kind {
+		case asNode, asStar:
+			if a.isZeroLength(s.node) {
+				return true
+			}
+		case asAcceptAll:
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the set of states after the automaton reads `c` in any of the
+// states in `set`.
+func (a *automaton) stepSet(set []int, c rune) []int {
+	var next []automatonState
+	for _, id := range set {
+		next = a.step(next, a.states[id], c)
+	}
+
+	ids := make([]int, 0, len(next))
+	seen := make(map[int]bool, len(next))
+	for _, s := range next {
+		if id := a.id(s); !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Appends the states after the automaton reads `c` in state `s` to `out`.
+func (a *automaton) step(out []automatonState, s automatonState, c rune) []automatonState {
+	switch s.kind {
+	case asNode:
+		return a.stepNode(out, s.node, s.sos, c)
+
+	case asStar:
+		out = a.stepNode(out, s.node, false, c)
+		if c != '/' {
+			out = append(out, s)
+		}
+
+	case asDoubleStar:
+		out = append(out, s)
+		if c == '/' {
+			out = append(out, automatonState{kind: asNode, node: s.node, sos: true})
+		}
+
+	case asAcceptAll:
+		out = append(out, s)
+	}
+	return out
+}
+
+// Appends the states after the automaton reads `c` at node `n` to `out`. `sos`
+// is true if `n` is at the start of a path segment.
+func (a *automaton) stepNode(out []automatonState, n int, sos bool, c rune) []automatonState {
+	if a.nodes[n].kind == anAlt {
+		// Match() starts a new path segment at the start of an alternative
+		for _, alt := range a.expandAlts(n) {
+			out = a.stepNode(out, alt, true, c)
+		}
+		return out
+	}
+
+	node := &a.nodes[n]
+	switch node.kind {
+	case anRune:
+		if c == node.r {
+			out = append(out, automatonState{kind: asNode, node: node.next, sos: c == '/'})
+		}
+
+	case anSeparator:
+		if c == '/' {
+			out = append(out, automatonState{kind: asNode, node: node.next, sos: true})
+		}
+
+	case anAny:
+		if c != '/' {
+			out = append(out, automatonState{kind: asNode, node: node.next})
+		}
+
+	case anClass:
+		if classMatches(node, c) {
+			out = append(out, automatonState{kind: asNode, node: node.next})
+		}
+
+	case anStar:
+		after := node.next
+		if a.nodes[after].kind == anStar {
+			// Match() always reads two stars together; it's a doublestar if it
+			// starts a path segment and is followed by a separator, or ends the
+			// pattern
+			after = a.nodes[after].next
+			if sos && a.nodes[after].kind == anEnd {
+				return append(out, automatonState{kind: asAcceptAll})
+			}
+			if sos && a.nodes[after].kind == anSeparator {
+				resume := a.nodes[after].next
+				out = a.stepNode(out, resume, true, c)
+				out = append(out, automatonState{kind: asDoubleStar, node: resume})
+				if c == '/' {
+					out = append(out, automatonState{kind: asNode, node: resume, sos: true})
+				}
+				return out
+			}
+		}
+
+		// otherwise, it's a star, which may match nothing, or `c`
+		out = a.stepNode(out, after, false, c)
+		if c != '/' {
+			out = append(out, automatonState{kind: asStar, node: after})
+		}
+	}
+	return out
+}
+
+// Returns the nodes that Match() might read first when it reaches the
+// alternation at node `n`: the first node of each alternative, expanding
+// nested alternations. The result is cached, since alternations such as
+// `{,}{,}{,}` would otherwise take exponential time to expand.
+func (a *automaton) expandAlts(n int) []int {
+	if expanded, ok := a.alts[n]; ok {
+		return expanded
+	}
+
+	var expanded []int
+	seen := make(map[int]bool)
+	var expand func(n int)
+	expand = func(n int) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if a.nodes[n].kind == anAlt {
+			for _, alt := range a.nodes[n].alts {
+				expand(alt)
+			}
+			return
+		}
+		expanded = append(expanded, n)
+	}
+	expand(n)
+
+	a.alts[n] = expanded
+	return expanded
+}
+
+// Returns true if the rest of the pattern, starting at node `n`, would match
+// when Match() runs out of name. Match() only considers the rest of the
+// pattern to match if it is exactly ``, `*`, `**`, or `/**`, possibly after
+// expanding alternations at the very beginning.
+func (a *automaton) isZeroLength(n int) bool {
+	zero, ok := a.zero[n]
+	if !ok {
+		zero = a.isZeroLengthTail(n, nil)
+		a.zero[n] = zero
+	}
+	return zero
+}
+
+func (a *automaton) isZeroLengthTail(n int, tail []automatonNodeKind) bool {
+	node := &a.nodes[n]
+	switch node.kind {
+	case anEnd:
+		switch len(tail) {
+		case 0:
+			return true
+		case 1:
+			return tail[0] == anStar
+		case 2:
+			return tail[0] == anStar && tail[1] == anStar
+		case 3:
+			return tail[0] == anSeparator && tail[1] == anStar && tail[2] == anStar
+		}
+		return false
+
+	case anAlt:
+		if len(tail) > 0 {
+			return false
+		}
+		for _, alt := range node.alts {
+			if a.isZeroLength(alt) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(tail) == 3 {
+		return false
+	}
+	return a.isZeroLengthTail(node.next, append(tail, node.kind))
+}
+
+// Returns true if the class matches `c`. Like Match(), a range with reversed
+// bounds only matches its first character.
+func classMatches(node *automatonNode, c rune) bool {
+	for _, r := range node.ranges {
+		if c == r.Lo || (r.Lo <= c && c <= r.Hi) {
+			return !node.negated
+		}
+	}
+	return node.negated
+}
+
+// Returns one rune from each range of runes that all of the automata treat
+// the same way. Runes that cannot appear in a valid UTF-8 string are skipped.
+func alphabet(automata ...*automaton) []rune {
+	cuts := map[rune]bool{0: true, '/': true, '/' + 1: true}
+	for _, a := range automata {
+		for _, n := range a.nodes {
+			switch n.kind {
+			case anRune:
+				cuts[n.r] = true
+				cuts[n.r+1] = true
+			case anClass:
+				for _, r := range n.ranges {
+					hi := r.Hi
+					if hi < r.Lo {
+						hi = r.Lo
+					}
+					cuts[r.Lo] = true
+					cuts[hi+1] = true
+				}
+			}
+		}
+	}
+
+	sorted := make([]rune, 0, len(cuts))
+	for r := range cuts {
+		if r >= 0 && r <= utf8.MaxRune {
+			sorted = append(sorted, r)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	symbols := make([]rune, 0, len(sorted))
+	for i, lo := range sorted {
+		hi := rune(utf8.MaxRune)
+		if i+1 < len(sorted) {
+			hi = sorted[i+1] - 1
+		}
+		if r, ok := representative(lo, hi); ok {
+			symbols = append(symbols, r)
+		}
+	}
+	return symbols
+}
+
+// Picks a rune from the range [lo, hi], preferring letters and digits so that
+// example names are readable.
+func representative(lo, hi rune) (rune, bool) {
+	for _, r := range "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ" {
+		if lo <= r && r <= hi {
+			return r, true
+		}
+	}
+	for _, r := range []rune{lo, 0xE000} {
+		if lo <= r && r <= hi && utf8.ValidRune(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// Limits on the work findName() will do: the number of pairs of sets of
+// states it visits, and the number of times it steps a state with a rune. The
+// number of pairs can grow exponentially with the length of the patterns: for
+// example, with `*a` followed by n `?`, the automaton has to remember which of
+// the last n+1 runes were an `a`, which takes 2^(n+1) sets.
+const (
+	maxFindNameSets  = 10000
+	maxFindNameSteps = 1000000
+)
+
+// Searches for the shortest name where `want(a matches, b matches)` is true.
+// Since `want` is only used to look for names that b matches, the search
+// doesn't bother with names b can't match. Returns ErrTooComplex if the search
+// would take more work than maxFindNameSets or maxFindNameSteps allow.
+func findName(a, b *automaton, want func(aMatches, bMatches bool) bool) (string, bool, error) {
+	type node struct {
+		a, b []int
+		prev int
+		c    rune
+	}
+
+	symbols := alphabet(a, b)
+	queue := []node{{a: a.startSet(), b: b.startSet(), prev: -1}}
+	seen := map[string]bool{setsKey(queue[0].a, queue[0].b): true}
+	steps := 0
+	for i := 0; i < len(queue); i++ {
+		if want(a.accepts(queue[i].a), b.accepts(queue[i].b)) {
+			var runes []rune
+			for j := i; queue[j].prev >= 0; j = queue[j].prev {
+				runes = append(runes, queue[j].c)
+			}
+			for l, r := 0, len(runes)-1; l < r; l, r = l+1, r-1 {
+				runes[l], runes[r] = runes[r], runes[l]
+			}
+			return string(runes), true, nil
+		}
+
+		if steps += len(symbols) * (len(queue[i].a) + len(queue[i].b)); steps > maxFindNameSteps {
+			return "", false, ErrTooComplex
+		}
+		for _, c := range symbols {
+			next := node{a: a.stepSet(queue[i].a, c), b: b.stepSet(queue[i].b, c), prev: i, c: c}
+			if len(next.b) == 0 || (len(next.a) == 0 && !want(false, true)) {
+				continue
+			}
+			if key := setsKey(next.a, next.b); !seen[key] {
+				if len(queue) >= maxFindNameSets {
+					return "", false, ErrTooComplex
+				}
+				seen[key] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return "", false, nil
+}
+
+func setsKey(a, b []int) string {
+	var sb strings.Builder
+	for _, id := range a {
+		sb.WriteString(strconv.Itoa(id))
+		sb.WriteByte(',')
+	}
+	sb.WriteByte('|')
+	for _, id := range b {
+		sb.WriteString(strconv.Itoa(id))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
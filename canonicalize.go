@@ -0,0 +1,327 @@
+package doublestar
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+// The maximum number of times Canonicalize will re-run its simplifications.
+// Each pass may enable more simplifications in the next, but in practice,
+// patterns settle down after two or three passes.
+const maxCanonicalizePasses = 8
+
+// Canonicalize rewrites a pattern into a canonical form, such that equivalent
+// patterns written in different ways are more likely to produce the same
+// string. This is useful for deduplicating or caching patterns. The returned
+// pattern is guaranteed to have the same behavior as the original in Match():
+//
+//   - `**/**/` is collapsed to `**/`
+//   - a `**` that isn't the only thing in its path segment, or a run of
+//     stars such as `***`, is replaced with `*`
+//   - alternatives are sorted and duplicates are removed, and alternatives
+//     that are themselves just an alternation are merged into the parent
+//   - alternations with only one alternative, such as `{a}`, are replaced by
+//     that alternative
+//   - character classes with a single character, such as `[a]`, are replaced
+//     by that character
+//   - overlapping or adjacent ranges in character classes are merged, and
+//     ranges with reversed bounds, such as `z-a`, are replaced by their first
+//     character (which is the only character they match)
+//   - unnecessary escapes are removed, and all negated character classes use
+//     `^`
+//
+// Some of these simplifications are skipped when they would change the
+// behavior of Match() in corner cases. For example, Match("a/***", "a/") is
+// false, whereas Match("a/*", "a/") is true, so a run of stars at the end of
+// the pattern is left alone. Canonicalize(Canonicalize(p)) will always equal
+// Canonicalize(p).
+//
+// Canonicalize assumes the pattern uses `/` as the path separator. The only
+// possible returned error is ErrBadPattern, when pattern is malformed. This
+// includes patterns where a character class contains an alternation's `,`,
+// `{`, or `}`, such as `{[,]}`, which Match() also rejects (see
+// syntax.Parse()).
+//
+func Canonicalize(pattern string) (string, error) {
+	for i := 0; i < maxCanonicalizePasses; i++ {
+		root, err := syntax.Parse(pattern)
+		if err != nil {
+			return "", ErrBadPattern
+		}
+
+		canonical := canonicalizeSequence(root, false).String()
+		if canonical == pattern {
+			break
+		}
+		pattern = canonical
+	}
+	return pattern, nil
+}
+
+// Returns a new, canonicalized copy of a Sequence. `alternative` is true if
+// the sequence is one of the alternatives of an Alternation: Match() splices
+// the alternative into the pattern, so stars at its start or end may run
+// together with stars around the Alternation.
+func canonicalizeSequence(seq *syntax.Node, alternative bool) *syntax.Node {
+	children := make([]*syntax.Node, 0, len(seq.Children))
+	for _, n := range seq.Children {
+		switch n.Kind {
+		case syntax.Literal:
+			children = append(children, &syntax.Node{Kind: syntax.Literal, Text: n.Text})
+		case syntax.Class:
+			children = append(children, canonicalizeClass(n))
+		case syntax.Alternation:
+			children = append(children, canonicalizeAlternation(n))
+		default:
+			children = append(children, &syntax.Node{Kind: n.Kind})
+		}
+	}
+
+	for unwrapped := true; unwrapped; {
+		// unwrapping an empty alternation, such as the second `{}` in `a{}{}b`,
+		// may make it safe to unwrap the one before it
+		children, unwrapped = unwrapAlternations(children)
+	}
+	children = simplifyStars(children, alternative)
+	children = collapseDoubleStars(children, alternative)
+	children = mergeLiterals(children)
+	return &syntax.Node{Kind: syntax.Sequence, Children: children}
+}
+
+// Returns a new, canonicalized copy of a Class. If the class only matches a
+// single character, a Literal is returned instead.
+func canonicalizeClass(n *syntax.Node) *syntax.Node {
+	// Match() checks the first character of a range before it notices the
+	// range, so a range with reversed bounds, such as `z-a`, matches just `z`.
+	ranges := make([]syntax.Range, 0, len(n.Ranges))
+	for _, r := range n.Ranges {
+		if r.Lo > r.Hi {
+			r.Hi = r.Lo
+		}
+		ranges = append(ranges, r)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Lo < ranges[j].Lo
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.Lo <= last.Hi+1 {
+			if r.Hi > last.Hi {
+				last.Hi = r.Hi
+			}
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	ranges = merged
+
+	// A class that matches a single character is the same as that character,
+	// except for the separator: a class turns off doublestar handling for the
+	// rest of the segment, whereas a separator starts a new segment.
+	if !n.Negated && len(ranges) == 1 && ranges[0].Lo == ranges[0].Hi && ranges[0].Lo != '/' {
+		return &syntax.Node{Kind: syntax.Literal, Text: string(ranges[0].Lo)}
+	}
+	return &syntax.Node{Kind: syntax.Class, Negated: n.Negated, Ranges: ranges}
+}
+
+// Returns a new, canonicalized copy of an Alternation, with nested
+// alternations merged, and alternatives sorted and deduplicated.
+func canonicalizeAlternation(n *syntax.Node) *syntax.Node {
+	alts := make([]*syntax.Node, 0, len(n.Children))
+	seen := make(map[string]bool, len(n.Children))
+	var addAlt func(alt *syntax.Node)
+	addAlt = func(alt *syntax.Node) {
+		if len(alt.Children) == 1 && alt.Children[0].Kind == syntax.Alternation {
+			// `{a,{b,c}}` is the same as `{a,b,c}`
+			for _, c := range alt.Children[0].Children {
+				addAlt(c)
+			}
+			return
+		}
+		if s := alt.String(); !seen[s] {
+			seen[s] = true
+			alts = append(alts, alt)
+		}
+	}
+	for _, alt := range n.Children {
+		addAlt(canonicalizeSequence(alt, true))
+	}
+
+	sort.SliceStable(alts, func(i, j int) bool {
+		return alts[i].String() < alts[j].String()
+	})
+	return &syntax.Node{Kind: syntax.Alternation, Children: alts}
+}
+
+// Replaces alternations with a single alternative with the contents of that
+// alternative. When Match() processes an alternation, it starts a new path
+// segment as far as `**` is concerned, so this is only done when it won't
+// matter: if the alternation starts the sequence, follows a separator, or
+// doesn't start with a star.
+//
+// When Match() reaches the end of the name, it only considers the rest of the
+// pattern to match the empty string if it is exactly `*`, `**`, or `/**` (or
+// starts with an alternation), so an alternation after a separator is also
+// left alone if only stars follow it: `a/{*}*` doesn't match `a`, but `a/**`
+// does. Similarly, `**` is only a doublestar if it's followed by a separator
+// or the end of the pattern, so an alternation after `**` is left alone.
+//
+// Returns true if anything was unwrapped.
+func unwrapAlternations(children []*syntax.Node) ([]*syntax.Node, bool) {
+	out := make([]*syntax.Node, 0, len(children))
+	unwrapped := false
+	for i, n := range children {
+		if n.Kind != syntax.Alternation || len(n.Children) != 1 {
+			out = append(out, n)
+			continue
+		}
+
+		alt := n.Children[0]
+		first := (*syntax.Node)(nil)
+		if len(alt.Children) > 0 {
+			first = alt.Children[0]
+		} else if i+1 < len(children) {
+			first = children[i+1]
+		}
+
+		afterSeparator := len(out) > 0 && out[len(out)-1].Kind == syntax.Separator
+		safe := len(out) == 0 || (afterSeparator && !onlyStars(alt.Children, children[i+1:]))
+		if !safe && first != nil {
+			switch first.Kind {
+			case syntax.Literal, syntax.Any, syntax.Class:
+				safe = true
+			case syntax.Separator:
+				// a `**` followed by an alternation is just a `*`, but it would
+				// become a doublestar if it were followed by a separator
+				safe = out[len(out)-1].Kind != syntax.DoubleStar
+			}
+		}
+
+		if safe {
+			out = append(out, alt.Children...)
+			unwrapped = true
+		} else {
+			out = append(out, n)
+		}
+	}
+	return out, unwrapped
+}
+
+// Replaces runs of stars with a single star, wherever that won't change the
+// meaning of the pattern. Match() treats a run of stars textually: a `**` is
+// only a doublestar if it's exactly two stars, at the start of a path
+// segment, followed by a separator or the end of the pattern. Otherwise, the
+// run behaves like a single `*`. If `alternative` is true, runs at the start
+// or end of the sequence are left alone, because the stars around the
+// Alternation will become part of the run.
+func simplifyStars(children []*syntax.Node, alternative bool) []*syntax.Node {
+	out := make([]*syntax.Node, 0, len(children))
+	for i := 0; i < len(children); {
+		if !isStarNode(children[i]) {
+			out = append(out, children[i])
+			i++
+			continue
+		}
+
+		start, stars := i, 0
+		for ; i < len(children) && isStarNode(children[i]); i++ {
+			if children[i].Kind == syntax.DoubleStar {
+				stars += 2
+			} else {
+				stars++
+			}
+		}
+
+		var prev, next *syntax.Node
+		if len(out) > 0 {
+			prev = out[len(out)-1]
+		}
+		if i < len(children) {
+			next = children[i]
+		}
+
+		switch {
+		case stars == 1:
+			out = append(out, &syntax.Node{Kind: syntax.Star})
+		case alternative && (start == 0 || next == nil):
+			out = append(out, children[start:i]...)
+		case stars == 2 && (isBeforeInSegment(prev) || isInSegment(next)):
+			out = append(out, &syntax.Node{Kind: syntax.Star})
+		case stars == 2:
+			out = append(out, &syntax.Node{Kind: syntax.DoubleStar})
+		case next == nil:
+			// When Match() reaches the end of the name, it only considers the rest
+			// of the pattern to match the empty string if it is exactly `*` or
+			// `**`, so a longer run of stars at the end must be left alone.
+			out = append(out, children[start:i]...)
+		default:
+			out = append(out, &syntax.Node{Kind: syntax.Star})
+		}
+	}
+	return out
+}
+
+// Collapses `**/**/` to `**/`. This is only done if the first `**` starts a
+// path segment, and the second is followed by a separator, since `**/**` at
+// the end of a pattern does not match the empty string, whereas `**` does. If
+// `alternative` is true, we don't know what comes before the sequence, so a
+// `**` at the start doesn't count as starting a path segment.
+func collapseDoubleStars(children []*syntax.Node, alternative bool) []*syntax.Node {
+	out := make([]*syntax.Node, 0, len(children))
+	for _, n := range children {
+		l := len(out)
+		if n.Kind == syntax.Separator && l >= 3 &&
+			out[l-1].Kind == syntax.DoubleStar &&
+			out[l-2].Kind == syntax.Separator &&
+			out[l-3].Kind == syntax.DoubleStar &&
+			((l == 3 && !alternative) || (l > 3 && out[l-4].Kind == syntax.Separator)) {
+			// drop the second `**`, and don't add this separator
+			out = out[:l-1]
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// Merges adjacent literals.
+func mergeLiterals(children []*syntax.Node) []*syntax.Node {
+	out := children[:0]
+	for _, n := range children {
+		if l := len(out); l > 0 && n.Kind == syntax.Literal && out[l-1].Kind == syntax.Literal {
+			out[l-1] = &syntax.Node{Kind: syntax.Literal, Text: out[l-1].Text + n.Text}
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// Returns true if the nodes in `lists` are all stars.
+func onlyStars(lists ...[]*syntax.Node) bool {
+	for _, nodes := range lists {
+		for _, n := range nodes {
+			if !isStarNode(n) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isStarNode(n *syntax.Node) bool {
+	return n.Kind == syntax.Star || n.Kind == syntax.DoubleStar
+}
+
+// Returns true if the node before a `**` definitely means the `**` is not at
+// the start of a path segment. This is like isInSegment, except that a
+// literal ending in an escaped slash starts a new path segment.
+func isBeforeInSegment(n *syntax.Node) bool {
+	if n != nil && n.Kind == syntax.Literal && strings.HasSuffix(n.Text, "/") {
+		return false
+	}
+	return isInSegment(n)
+}
@@ -0,0 +1,38 @@
+//go:build go1.18
+// +build go1.18
+
+package doublestar
+
+import (
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+func FuzzCanonicalize(f *testing.F) {
+	for _, tt := range matchTests {
+		f.Add(tt.pattern, tt.testPath)
+	}
+	for _, tt := range canonicalizeTests {
+		f.Add(tt.pattern, tt.expected)
+	}
+
+	f.Fuzz(func(t *testing.T, pattern, name string) {
+		canonical, err := Canonicalize(pattern)
+		if err != nil {
+			if _, parseErr := syntax.Parse(pattern); parseErr == nil {
+				t.Fatalf("Canonicalize(%#q) returned error %v for a valid pattern", pattern, err)
+			}
+			return
+		}
+
+		if again, _ := Canonicalize(canonical); again != canonical {
+			t.Fatalf("Canonicalize(%#q) = %#q, but Canonicalize(%#q) = %#q", pattern, canonical, canonical, again)
+		}
+
+		expected, _ := Match(pattern, name)
+		if ok, _ := Match(canonical, name); ok != expected {
+			t.Fatalf("Match(%#q, %#q) = %v, but Match(%#q, %#q) = %v", pattern, name, expected, canonical, name, ok)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package doublestar
+
+import "testing"
+
+type CanonicalizeTest struct {
+	pattern  string // pattern to canonicalize
+	expected string // expected canonical pattern
+}
+
+var canonicalizeTests = []CanonicalizeTest{
+	{"", ""},
+	{"a/**/b/*.txt", "a/**/b/*.txt"},
+	{"**/**/a", "**/a"},
+	{"a/**/**/**/b", "a/**/b"},
+	{"**/**", "**/**"},
+	{"a/**/**", "a/**/**"},
+	{"a**", "a*"},
+	{"**a", "*a"},
+	{"path/to/**.txt", "path/to/*.txt"},
+	{"a/***/b", "a/*/b"},
+	{"a/***", "a/***"},
+	{"{b,a,b}", "{a,b}"},
+	{"{a,{c,b}}", "{a,b,c}"},
+	{"{a}", "a"},
+	{"x/{a/*}", "x/a/*"},
+	{"{*}", "*"},
+	{"a{*}", "a{*}"},
+	{"a{**/b}", "a{**/b}"},
+	{"a/{**}", "a/{**}"},
+	{"a/{*}*", "a/{*}*"},
+	{"src/{*,*}*", "src/{*}*"},
+	{"a/{}**", "a/{}**"},
+	{"a/{*}b", "a/*b"},
+	{"{0**}*", "0***"},
+	{"{a**}", "a*"},
+	{"x{**/**/a}", "x{**/**/a}"},
+	{"x/{**/**/a}", "x/**/a"},
+	{"0{}{}{}{}{}{}{}{}{}0", "00"},
+	{"a/{}{}*", "a/{}*"},
+	{"a/**{}/*", "a/**{}/*"},
+	{"a/**{}", "a/**{}"},
+	{"[a]", "a"},
+	{"[*]", "\\*"},
+	{"[/]", "[/]"},
+	{"[^a]", "[^a]"},
+	{"[!a]", "[^a]"},
+	{"[c-da-b]", "[a-d]"},
+	{"[a-cb-e]", "[a-e]"},
+	{"[z-aq]", "[qz]"},
+	{"[z-a]", "z"},
+	{"\\a\\b", "ab"},
+	{"\\]", "]"},
+	{"a\\/b", "a\\/b"},
+	{"{a\\,b,c}", "{a\\,b,c}"},
+	{"\\,", ","},
+	{"{[a],a}b", "ab"},
+	{"{[{,}],}", "{,[\\,\\{\\}]}"},
+}
+
+// Paths to check that each canonical pattern matches the same as the original.
+var canonicalizeTestPaths = []string{"", "a", "a/", "a/b", "a/bc", "a/b/c", "ab", "src", "src/x", "/", "/x", "x/a/b", "*", "]", ","}
+
+func TestCanonicalize(t *testing.T) {
+	for idx, tt := range canonicalizeTests {
+		canonical, err := Canonicalize(tt.pattern)
+		if err != nil {
+			t.Errorf("#%v. Canonicalize(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+		if canonical != tt.expected {
+			t.Errorf("#%v. Canonicalize(%#q) = %#q - should be %#q", idx, tt.pattern, canonical, tt.expected)
+		}
+		if again, _ := Canonicalize(canonical); again != canonical {
+			t.Errorf("#%v. Canonicalize(%#q) = %#q - should be idempotent", idx, canonical, again)
+		}
+
+		for _, name := range canonicalizeTestPaths {
+			expected, _ := Match(tt.pattern, name)
+			if ok, _ := Match(canonical, name); ok != expected {
+				t.Errorf("#%v. Match(%#q, %#q) = %v - but Match(%#q, ...) = %v", idx, canonical, name, ok, tt.pattern, expected)
+			}
+		}
+	}
+}
+
+func TestCanonicalizeBadPattern(t *testing.T) {
+	for _, pattern := range []string{"a[", "a}", "{a", "\\", "{[,]}", "{[0{X]}"} {
+		if _, err := Canonicalize(pattern); err != ErrBadPattern {
+			t.Errorf("Canonicalize(%#q) = %v - should be ErrBadPattern", pattern, err)
+		}
+	}
+}
+
+func TestCanonicalizeMatchTests(t *testing.T) {
+	// the canonical pattern should match exactly the same paths as the original
+	var paths []string
+	for _, tt := range matchTests {
+		paths = append(paths, tt.testPath)
+	}
+
+	for idx, tt := range matchTests {
+		if tt.expectedErr != nil {
+			continue
+		}
+
+		canonical, err := Canonicalize(tt.pattern)
+		if err != nil {
+			t.Errorf("#%v. Canonicalize(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+
+		for _, name := range paths {
+			expected, expectedErr := Match(tt.pattern, name)
+			ok, err := Match(canonical, name)
+			if ok != expected || (err == nil) != (expectedErr == nil) {
+				t.Errorf("#%v. Match(%#q, %#q) = %v, %v - but Match(%#q, ...) = %v, %v", idx, canonical, name, ok, err, tt.pattern, expected, expectedErr)
+			}
+		}
+	}
+}
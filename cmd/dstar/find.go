@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const findUsage = `usage: dstar find [flags] pattern...
+
+Prints the paths that match any of the doublestar patterns, such as
+'src/**/*.go'. Quote patterns so that your shell doesn't expand them, and use
+'/' as the path separator, even on Windows. Hidden files and directories (whose
+names start with '.') are skipped unless --hidden is passed, but the part of a
+pattern before any wildcards, as in '.github/*.yml', is always searched.
+
+The exit status is 0 if anything matched, 1 if nothing matched, and 2 if there
+was an error, such as a malformed pattern.
+
+Flags:
+`
+
+// A repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+type findOptions struct {
+	excludes stringsFlag
+	print0   bool
+	json     bool
+	typ      string
+	maxDepth int
+	follow   bool
+	hidden   bool
+	count    bool
+}
+
+// A match, as written by --json.
+type findResult struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Runs `dstar find` and returns the exit status.
+func runFind(args []string, stdout, stderr io.Writer) int {
+	var opts findOptions
+	flags := flag.NewFlagSet("find", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), findUsage)
+		flags.PrintDefaults()
+	}
+	flags.Var(&opts.excludes, "exclude", "skip paths that match `pattern`, and anything below them (may be repeated)")
+	flags.BoolVar(&opts.print0, "print0", false, "separate paths with NUL instead of newline")
+	flags.BoolVar(&opts.json, "json", false, "print a JSON object with the path and type of each match, one per line")
+	flags.StringVar(&opts.typ, "type", "", "only print paths of `type` f (regular file), d (directory), or l (symlink)")
+	flags.IntVar(&opts.maxDepth, "max-depth", -1, "don't descend more than `n` directories below the start of each pattern")
+	flags.BoolVar(&opts.follow, "follow", false, "follow symlinks to directories")
+	flags.BoolVar(&opts.hidden, "hidden", false, "include hidden files and directories")
+	flags.BoolVar(&opts.count, "count", false, "only print the number of matches")
+
+	patterns, err := parseInterspersed(flags, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+	if len(patterns) == 0 {
+		fmt.Fprintln(stderr, "dstar find: no patterns")
+		flags.Usage()
+		return 2
+	}
+	switch opts.typ {
+	case "", "f", "d", "l":
+	default:
+		fmt.Fprintf(stderr, "dstar find: invalid --type %q: must be f, d, or l\n", opts.typ)
+		return 2
+	}
+	for _, p := range append(patterns, opts.excludes...) {
+		if !doublestar.ValidatePattern(p) {
+			fmt.Fprintf(stderr, "dstar find: %q: %v\n", p, doublestar.ErrBadPattern)
+			return 2
+		}
+	}
+
+	f := &finder{opts: opts, stdout: stdout, stderr: stderr, seen: make(map[string]bool)}
+	if opts.json {
+		f.enc = json.NewEncoder(stdout)
+	}
+	for _, p := range patterns {
+		if err := f.find(p); err != nil {
+			fmt.Fprintf(stderr, "dstar find: %v\n", err)
+			return 2
+		}
+	}
+
+	if opts.count {
+		if f.enc != nil {
+			f.enc.Encode(struct {
+				Count int `json:"count"`
+			}{f.matches})
+		} else {
+			fmt.Fprintln(stdout, f.matches)
+		}
+	}
+	if f.matches == 0 {
+		return 1
+	}
+	return 0
+}
+
+// Parses `args`, allowing flags to appear after patterns, as in
+// `dstar find '**/*.go' --count`. Everything after `--` is a pattern.
+func parseInterspersed(flags *flag.FlagSet, args []string) (patterns []string, err error) {
+	for {
+		if err = flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if len(rest) == 0 {
+			return patterns, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(patterns, rest...), nil
+		}
+		patterns = append(patterns, rest[0])
+		args = rest[1:]
+	}
+}
+
+type finder struct {
+	opts    findOptions
+	stdout  io.Writer
+	stderr  io.Writer
+	enc     *json.Encoder
+	seen    map[string]bool
+	matches int
+}
+
+// Finds the matches for one pattern.
+func (f *finder) find(pattern string) error {
+	for _, split := range doublestar.SplitPatternAll(pattern) {
+		base := doublestar.Unescape(split.Base)
+		fsys := &findFS{
+			FS:       os.DirFS(base),
+			base:     base,
+			follow:   f.opts.follow,
+			hidden:   f.opts.hidden,
+			maxDepth: f.opts.maxDepth,
+			exclude:  f.isExclude,
+		}
+
+		err := doublestar.GlobWalk(fsys, split.Pattern, func(p string, d fs.DirEntry) error {
+			return f.match(fsys, joinBase(base, p), p, d)
+		}, doublestar.WithErrorHandler(func(p string, err error) error {
+			// like find, report the error and keep going
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) {
+				err = pathErr.Err
+			}
+			fmt.Fprintf(f.stderr, "dstar find: %s: %v\n", filepath.FromSlash(joinBase(base, p)), err)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prints the match `name` (which is `p` joined to the base path) if it passes
+// the filters.
+func (f *finder) match(fsys *findFS, name, p string, d fs.DirEntry) error {
+	if f.seen[name] || f.excluded(name) {
+		return nil
+	}
+	if f.opts.maxDepth >= 0 && depth(p) > f.opts.maxDepth {
+		return nil
+	}
+
+	typ := modeType(d.Type())
+	if typ == "l" && f.opts.follow {
+		// like find -L, report the type of the target
+		if info, err := fs.Stat(fsys.FS, p); err == nil {
+			typ = modeType(info.Mode())
+		}
+	}
+	if f.opts.typ != "" && typ != f.opts.typ {
+		return nil
+	}
+
+	f.seen[name] = true
+	f.matches++
+	if f.opts.count {
+		return nil
+	}
+
+	name = filepath.FromSlash(name)
+	var err error
+	switch {
+	case f.enc != nil:
+		err = f.enc.Encode(findResult{Path: name, Type: typ})
+	case f.opts.print0:
+		_, err = io.WriteString(f.stdout, name+"\x00")
+	default:
+		_, err = io.WriteString(f.stdout, name+"\n")
+	}
+	return err
+}
+
+// Returns true if `name`, or any directory it's in, matches an exclude.
+// findFS already skips anything below an excluded directory, but the base
+// path of a pattern, or any directory it's in, may also be excluded.
+func (f *finder) excluded(name string) bool {
+	for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		if f.isExclude(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if `name` matches an exclude.
+func (f *finder) isExclude(name string) bool {
+	for _, ex := range f.opts.excludes {
+		if ok, _ := doublestar.Match(ex, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func joinBase(base, p string) string {
+	switch {
+	case p == ".":
+		return base
+	case base == ".":
+		return p
+	case strings.HasSuffix(base, "/"):
+		return base + p
+	}
+	return base + "/" + p
+}
+
+// Returns the number of directories below the base path that `p` is in,
+// plus one, so that `a` has a depth of 1 and `a/b` has a depth of 2.
+func depth(p string) int {
+	if p == "." {
+		return 0
+	}
+	return strings.Count(p, "/") + 1
+}
+
+// Returns the --type of a file with mode `m`, or "?" for other kinds of
+// files, such as devices.
+func modeType(m fs.FileMode) string {
+	switch {
+	case m&fs.ModeSymlink != 0:
+		return "l"
+	case m.IsDir():
+		return "d"
+	case m.IsRegular():
+		return "f"
+	}
+	return "?"
+}
+
+// findFS wraps os.DirFS to implement --exclude, --follow, --hidden, and
+// --max-depth by pruning the directories that GlobWalk() sees.
+type findFS struct {
+	fs.FS
+	base     string
+	follow   bool
+	hidden   bool
+	maxDepth int
+
+	// returns true if a path, joined to the base path, is excluded
+	exclude func(name string) bool
+}
+
+// Without --follow, Stat doesn't follow symlinks, so GlobWalk() never treats
+// a symlink as a directory. The base path itself is always followed.
+func (f *findFS) Stat(name string) (fs.FileInfo, error) {
+	if f.follow || name == "." {
+		return fs.Stat(f.FS, name)
+	}
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Lstat(filepath.Join(f.base, filepath.FromSlash(name)))
+}
+
+func (f *findFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.maxDepth >= 0 && depth(name) >= f.maxDepth {
+		return nil, nil
+	}
+
+	entries, err := fs.ReadDir(f.FS, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// excluded entries are dropped here, rather than when they match, so that
+	// GlobWalk() never reads excluded directories
+	visible := entries[:0]
+	for _, e := range entries {
+		if !f.hidden && strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if f.exclude != nil && f.exclude(joinBase(f.base, path.Join(name, e.Name()))) {
+			continue
+		}
+		visible = append(visible, e)
+	}
+	return visible, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Creates a tree for testing in a temporary directory, changes into it, and
+// returns whether symlinks could be created.
+func makeFindTree(t *testing.T) bool {
+	dir := t.TempDir()
+	for _, name := range []string{"a/x.go", "a/b/y.go", "a/b/c/z.go", "a/.hidden/h.go", "a/README", "real/r.go", "vendor/v.go"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	symlinks := os.Symlink(filepath.Join("..", "real"), filepath.Join(dir, "a", "link")) == nil
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return symlinks
+}
+
+func runFindTest(args ...string) (lines []string, stderr string, status int) {
+	var out, errOut bytes.Buffer
+	status = run(append([]string{"find"}, args...), &out, &errOut)
+	if s := strings.TrimSuffix(out.String(), "\n"); s != "" {
+		lines = strings.Split(filepath.ToSlash(s), "\n")
+	}
+	return lines, errOut.String(), status
+}
+
+func TestFind(t *testing.T) {
+	symlinks := makeFindTree(t)
+
+	type findTest struct {
+		args     []string
+		expected []string
+	}
+	tests := []findTest{
+		{[]string{"a/**/*.go"}, []string{"a/b/c/z.go", "a/b/y.go", "a/x.go"}},
+		{[]string{"a/*.go", "vendor/*.go", "a/x.go"}, []string{"a/x.go", "vendor/v.go"}},
+		{[]string{"**/*.go", "--exclude", "vendor", "--exclude", "a/b/c"}, []string{"a/b/y.go", "a/x.go", "real/r.go"}},
+		{[]string{"a/**/*.go", "--hidden"}, []string{"a/.hidden/h.go", "a/b/c/z.go", "a/b/y.go", "a/x.go"}},
+		{[]string{"a/.hidden/*"}, []string{"a/.hidden/h.go"}},
+		{[]string{"a/**", "--type", "d"}, []string{"a", "a/b", "a/b/c"}},
+		{[]string{"a/**", "--max-depth", "1", "--type", "f"}, []string{"a/README", "a/x.go"}},
+		{[]string{"--count", "**/*.go"}, []string{"5"}},
+		{[]string{"a/{x,b/y}.go", "--json"}, []string{`{"path":"a/b/y.go","type":"f"}`, `{"path":"a/x.go","type":"f"}`}},
+		{[]string{"--", "a/x.go"}, []string{"a/x.go"}},
+	}
+	if symlinks {
+		tests = append(tests,
+			findTest{[]string{"a/**/*.go"}, []string{"a/b/c/z.go", "a/b/y.go", "a/x.go"}},
+			findTest{[]string{"a/**/*.go", "--follow"}, []string{"a/b/c/z.go", "a/b/y.go", "a/link/r.go", "a/x.go"}},
+			findTest{[]string{"a/*", "--type", "l"}, []string{"a/link"}},
+			findTest{[]string{"a/*", "--type", "d", "--follow"}, []string{"a/b", "a/link"}},
+		)
+	}
+
+	for _, tt := range tests {
+		lines, stderr, status := runFindTest(tt.args...)
+		sort.Strings(lines)
+		if status != 0 || !reflect.DeepEqual(lines, tt.expected) {
+			t.Errorf("dstar find %v = %#v, status %d, stderr %q - should be %#v", tt.args, lines, status, stderr, tt.expected)
+		}
+	}
+}
+
+func TestFindPrunesExcludes(t *testing.T) {
+	makeFindTree(t)
+
+	// excluded directories must not even be read
+	f := &finder{opts: findOptions{excludes: stringsFlag{"**/b", "**/link", "vendor"}}}
+	for _, tt := range []struct {
+		base, dir string
+		expected  []string
+	}{
+		{".", ".", []string{"a", "real"}},
+		{".", "a", []string{"README", "x.go"}},
+		{"a", ".", []string{"README", "x.go"}},
+	} {
+		fsys := &findFS{FS: os.DirFS(tt.base), base: tt.base, maxDepth: -1, exclude: f.isExclude}
+		entries, err := fsys.ReadDir(tt.dir)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		if err != nil || !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("findFS{base: %q}.ReadDir(%q) = %v, %v - should be %v", tt.base, tt.dir, names, err, tt.expected)
+		}
+	}
+}
+
+func TestFindPrint0(t *testing.T) {
+	makeFindTree(t)
+
+	var out bytes.Buffer
+	if status := run([]string{"find", "-print0", "a/b/**/*.go"}, &out, &out); status != 0 {
+		t.Fatalf("dstar find -print0 has status %d: %s", status, out.String())
+	}
+	paths := strings.Split(strings.TrimSuffix(filepath.ToSlash(out.String()), "\x00"), "\x00")
+	sort.Strings(paths)
+	if expected := []string{"a/b/c/z.go", "a/b/y.go"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("dstar find -print0 = %#v - should be %#v", paths, expected)
+	}
+}
+
+func TestFindExitStatus(t *testing.T) {
+	makeFindTree(t)
+
+	tests := []struct {
+		args   []string
+		status int
+	}{
+		{[]string{"**/*.rs"}, 1},
+		{[]string{"missing/*"}, 1},
+		{[]string{"--count", "**/*.rs"}, 1},
+		{[]string{"a/["}, 2},
+		{[]string{"a/*", "--exclude", "["}, 2},
+		{[]string{"a/*", "--type", "x"}, 2},
+		{[]string{"--no-such-flag", "a/*"}, 2},
+		{nil, 2},
+		{[]string{"-h"}, 0},
+	}
+	for _, tt := range tests {
+		if _, stderr, status := runFindTest(tt.args...); status != tt.status {
+			t.Errorf("dstar find %v has status %d, stderr %q - should be %d", tt.args, status, stderr, tt.status)
+		}
+	}
+
+	var out bytes.Buffer
+	if status := run([]string{"nope"}, &out, &out); status != 2 {
+		t.Errorf("dstar nope has status %d - should be 2", status)
+	}
+}
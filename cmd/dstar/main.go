@@ -0,0 +1,51 @@
+// Command dstar finds files using doublestar patterns.
+//
+// Usage:
+//
+//   dstar find [flags] pattern...
+//
+// Run `dstar help find` for the list of flags.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: dstar <command> [arguments]
+
+Commands:
+  find    find files matching doublestar patterns
+  help    show help for a command
+
+Run 'dstar help <command>' for more information about a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Runs the command given by `args` and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "find":
+		return runFind(args[1:], stdout, stderr)
+
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 && args[1] == "find" {
+			runFind([]string{"-h"}, stdout, stdout)
+			return 0
+		}
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "dstar: unknown command %q\n\n%s", args[0], usage)
+	return 2
+}
@@ -0,0 +1,191 @@
+package doublestar
+
+import (
+	"container/list"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirCache caches directory listings and stat results so that they can be
+// shared across calls to Glob, GlobWalk, and the like. See WithDirCache.
+//
+// A DirCache only knows about paths, not the fs.FS they came from, so it
+// should only be shared between calls that glob the same fs.FS (FilepathGlob,
+// FilepathGlobWalk, OSGlob, and OSGlobWalk are the exception: they cache
+// paths joined with their base path, so they may share a DirCache no matter
+// what their patterns are).
+//
+// A DirCache is safe for concurrent use. The zero value is an empty cache
+// with no TTL and no size bound.
+type DirCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[dirCacheKey]*list.Element
+	lru     list.List
+
+	// for tests
+	now func() time.Time
+}
+
+type dirCacheKey struct {
+	readDir bool
+	name    string
+}
+
+type dirCacheEntry struct {
+	key     dirCacheKey
+	expires time.Time
+	dirs    []fs.DirEntry
+	info    fs.FileInfo
+	err     error
+}
+
+// NewDirCache creates a DirCache. If `ttl` is greater than zero, cached
+// results expire after `ttl`. If `maxEntries` is greater than zero, at most
+// `maxEntries` results are cached, and the least recently used are evicted
+// first.
+//
+func NewDirCache(ttl time.Duration, maxEntries int) *DirCache {
+	return &DirCache{ttl: ttl, maxEntries: maxEntries}
+}
+
+// WithDirCache is an option that can be passed to Glob, GlobWalk, or
+// FilepathGlob. If passed, directory listings and stat results are looked up
+// in `c` before calling fs.ReadDir() or fs.Stat(), and the results of those
+// calls are stored in `c` for later. Errors are cached, too, so a path that
+// doesn't exist won't be stat'd again until it expires or is invalidated.
+//
+func WithDirCache(c *DirCache) GlobOption {
+	return func(g *glob) {
+		g.dirCache = c
+	}
+}
+
+// Invalidate removes any cached results for `name`, anything below it, and
+// the listing of its parent directory, for callers who know that `name` has
+// changed. `name` is a path relative to the fs.FS, or, for FilepathGlob and
+// the like, an OS path (ie, the base path joined with the relative path).
+//
+func (c *DirCache) Invalidate(name string) {
+	name = dirCacheName(name)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	} else if name == "/" {
+		prefix = "/"
+	}
+	for key, el := range c.entries {
+		if key.name == name || strings.HasPrefix(key.name, prefix) {
+			c.remove(el)
+		}
+	}
+	if el, ok := c.entries[dirCacheKey{true, path.Dir(name)}]; ok {
+		c.remove(el)
+	}
+}
+
+// Calls fs.ReadDir() if the listing of `name` isn't already cached. `key` is
+// the name to cache the result under.
+func (c *DirCache) readDir(fsys fs.FS, name, key string, stats *GlobStats) ([]fs.DirEntry, error) {
+	k := dirCacheKey{true, dirCacheName(key)}
+	if e := c.get(k); e != nil {
+		return e.dirs, e.err
+	}
+
+	if stats != nil {
+		stats.ReadDirCalls++
+	}
+	dirs, err := fs.ReadDir(fsys, name)
+	c.put(&dirCacheEntry{key: k, dirs: dirs, err: err})
+	return dirs, err
+}
+
+// Calls fs.Stat() if the result for `name` isn't already cached. `key` is the
+// name to cache the result under.
+func (c *DirCache) stat(fsys fs.FS, name, key string, stats *GlobStats) (fs.FileInfo, error) {
+	k := dirCacheKey{false, dirCacheName(key)}
+	if e := c.get(k); e != nil {
+		return e.info, e.err
+	}
+
+	if stats != nil {
+		stats.StatCalls++
+	}
+	info, err := fs.Stat(fsys, name)
+	c.put(&dirCacheEntry{key: k, info: info, err: err})
+	return info, err
+}
+
+// Returns the cached entry for `key`, or nil if there isn't one or it has
+// expired.
+func (c *DirCache) get(key dirCacheKey) *dirCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+
+	e := el.Value.(*dirCacheEntry)
+	if c.ttl > 0 && !c.timeNow().Before(e.expires) {
+		c.remove(el)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+// Caches `e`, evicting the least recently used entries if there are too many.
+func (c *DirCache) put(e *dirCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[dirCacheKey]*list.Element)
+	}
+	if c.ttl > 0 {
+		e.expires = c.timeNow().Add(c.ttl)
+	}
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Removes an entry - c.mu must be held.
+func (c *DirCache) remove(el *list.Element) {
+	delete(c.entries, el.Value.(*dirCacheEntry).key)
+	c.lru.Remove(el)
+}
+
+func (c *DirCache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Cleans up a name so that `a`, `./a`, and `a/` are all cached the same.
+func dirCacheName(name string) string {
+	if name == "" {
+		return "."
+	}
+	return path.Clean(filepath.ToSlash(name))
+}
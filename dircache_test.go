@@ -0,0 +1,125 @@
+package doublestar
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDirCache(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b.go":   {},
+		"a/c.txt":  {},
+		"a/d/e.go": {},
+	}
+	cache := NewDirCache(0, 0)
+
+	var stats GlobStats
+	matches, _ := Glob(fsys, "a/**/*.go", WithDirCache(cache), WithStats(&stats))
+	if len(matches) != 2 || stats.ReadDirCalls == 0 {
+		t.Errorf("Glob(`a/**/*.go`, WithDirCache) = %#v, stats = %+v - should have 2 results", matches, stats)
+	}
+
+	// the second time, everything should come from the cache, even new files
+	fsys["a/f.go"] = &fstest.MapFile{}
+	stats = GlobStats{}
+	matches, _ = Glob(fsys, "a/**/*.go", WithDirCache(cache), WithStats(&stats))
+	if len(matches) != 2 || stats.ReadDirCalls != 0 || stats.StatCalls != 0 {
+		t.Errorf("Glob(`a/**/*.go`, WithDirCache) = %#v, stats = %+v - should have 2 results from the cache", matches, stats)
+	}
+
+	// until the new file is invalidated
+	cache.Invalidate("a/f.go")
+	stats = GlobStats{}
+	matches, _ = Glob(fsys, "a/**/*.go", WithDirCache(cache), WithStats(&stats))
+	if len(matches) != 3 || stats.ReadDirCalls != 1 {
+		t.Errorf("Glob(`a/**/*.go`, WithDirCache) after Invalidate = %#v, stats = %+v - should have 3 results and read `a` again", matches, stats)
+	}
+
+	// stat results, including errors, are cached, too
+	stats = GlobStats{}
+	Glob(fsys, "a/{b,x}.go", WithDirCache(cache), WithStats(&stats))
+	Glob(fsys, "a/{b,x}.go", WithDirCache(cache), WithStats(&stats))
+	if stats.StatCalls != 2 || stats.ErrorsIgnored != 2 {
+		t.Errorf("Glob(`a/{b,x}.go`, WithDirCache) twice, stats = %+v - should have stat'd twice and ignored 2 errors", stats)
+	}
+
+	// invalidating a directory invalidates everything below it
+	cache.Invalidate("a")
+	for key := range cache.entries {
+		t.Errorf("Invalidate(`a`) left %+v in the cache", key)
+	}
+}
+
+func TestDirCacheTTL(t *testing.T) {
+	fsys := fstest.MapFS{"a": {}}
+	now := time.Unix(0, 0)
+	cache := NewDirCache(time.Minute, 0)
+	cache.now = func() time.Time { return now }
+
+	matches, _ := Glob(fsys, "*", WithDirCache(cache))
+	fsys["b"] = &fstest.MapFile{}
+	now = now.Add(59 * time.Second)
+	if matches, _ = Glob(fsys, "*", WithDirCache(cache)); len(matches) != 1 {
+		t.Errorf("Glob(`*`, WithDirCache) before the TTL = %#v - should have 1 result", matches)
+	}
+	now = now.Add(time.Second)
+	if matches, _ = Glob(fsys, "*", WithDirCache(cache)); len(matches) != 2 {
+		t.Errorf("Glob(`*`, WithDirCache) after the TTL = %#v - should have 2 results", matches)
+	}
+}
+
+func TestDirCacheMaxEntries(t *testing.T) {
+	fsys := fstest.MapFS{"a/x": {}, "b/x": {}, "c/x": {}}
+	cache := NewDirCache(0, 2)
+
+	var stats GlobStats
+	for _, p := range []string{"a/*", "b/*", "a/*", "c/*", "a/*", "b/*"} {
+		Glob(fsys, p, WithDirCache(cache), WithStats(&stats))
+	}
+	// `a` is used most recently, so `b` is evicted by `c`, and read again
+	if stats.ReadDirCalls != 4 || cache.lru.Len() != 2 {
+		t.Errorf("Glob() with a DirCache of 2 entries, stats = %+v, %v entries - should have read 4 directories and cached 2", stats, cache.lru.Len())
+	}
+}
+
+func TestDirCacheOnDisk(t *testing.T) {
+	fsys := os.DirFS("test")
+	var cache DirCache
+	for pass := 0; pass < 2; pass++ {
+		for idx, tt := range matchTests {
+			if !tt.testOnDisk {
+				continue
+			}
+
+			expected, expectedErr := Glob(fsys, tt.pattern)
+			matches, err := Glob(fsys, tt.pattern, WithDirCache(&cache))
+			if !compareSlices(matches, expected) || err != expectedErr {
+				t.Errorf("#%v. Glob(%#q, WithDirCache) = %#v, %v - should be %#v, %v", idx, tt.pattern, matches, err, expected, expectedErr)
+			}
+
+			var walked []string
+			err = GlobWalk(fsys, tt.pattern, func(p string, d fs.DirEntry) error {
+				walked = append(walked, p)
+				return nil
+			}, WithDirCache(&cache))
+			if !compareSlices(walked, expected) || err != expectedErr {
+				t.Errorf("#%v. GlobWalk(%#q, WithDirCache) = %#v, %v - should be %#v, %v", idx, tt.pattern, walked, err, expected, expectedErr)
+			}
+		}
+	}
+
+	// FilepathGlob caches OS paths, so it can share a cache no matter the base
+	// path
+	cache = DirCache{}
+	a, _ := FilepathGlob("test/a/*", WithDirCache(&cache))
+	b, _ := FilepathGlob("test/*/*", WithDirCache(&cache))
+	if len(a) == 0 || len(b) <= len(a) {
+		t.Errorf("FilepathGlob() with a shared DirCache = %#v and %#v - the second should have more results", a, b)
+	}
+	if _, ok := cache.entries[dirCacheKey{true, "test/a"}]; !ok {
+		t.Errorf("FilepathGlob(`test/a/*`, WithDirCache) didn't cache `test/a`")
+	}
+}
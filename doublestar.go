@@ -1,8 +1,65 @@
 package doublestar
 
 import (
+	"errors"
 	"path"
+	"strings"
 )
 
 // ErrBadPattern indicates a pattern was malformed.
 var ErrBadPattern = path.ErrBadPattern
+
+// GlobIOError records an IO error encountered while globbing. If the
+// WithCollectIOErrors option is passed, every IO error is recorded as a
+// GlobIOError. `Path` is the path that failed, relative to the fs.FS. `Op` is
+// the operation that failed, either "readdir" or "stat".
+type GlobIOError struct {
+	Path string
+	Op   string
+	Err  error
+}
+
+func (e *GlobIOError) Error() string {
+	return e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *GlobIOError) Unwrap() error {
+	return e.Err
+}
+
+// joinedIOErrors is the error returned when the WithCollectIOErrors option is
+// passed and IO errors were encountered. It behaves like the error returned by
+// errors.Join().
+type joinedIOErrors []error
+
+func (e joinedIOErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e joinedIOErrors) Unwrap() []error {
+	return e
+}
+
+// errors.Is() and errors.As() only use Unwrap() []error since go 1.20, so,
+// for older versions, Is() and As() check each error themselves.
+func (e joinedIOErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e joinedIOErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package doublestar
 
 import (
+	"errors"
 	"io/fs"
 	"log"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 type MatchTest struct {
@@ -421,6 +423,162 @@ func testStandardGlob(t *testing.T, idx int, fn string, tt MatchTest, fsys fs.FS
 	}
 }
 
+func TestGlobWithErrorHandler(t *testing.T) {
+	fsys := os.DirFS("test")
+	for idx, tt := range matchTests {
+		if tt.testOnDisk {
+			testGlobWithErrorHandlerWith(t, idx, tt, fsys)
+		}
+	}
+}
+
+func testGlobWithErrorHandlerWith(t *testing.T, idx int, tt MatchTest, fsys fs.FS) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. Glob(%#q, WithErrorHandler) panicked: %#v", idx, tt.pattern, r)
+		}
+	}()
+
+	numResults := tt.numResults
+	if onWindows {
+		numResults = tt.winNumResults
+	}
+
+	// an error handler that ignores errors should act like the default
+	var handled []string
+	matches, err := Glob(fsys, tt.pattern, WithErrorHandler(func(p string, e error) error {
+		handled = append(handled, p)
+		return nil
+	}))
+	if len(matches) != numResults || err != tt.expectedErr {
+		t.Errorf("#%v. Glob(%#q, WithErrorHandler) = %#v, %v - should have %#v results, %v", idx, tt.pattern, matches, err, numResults, tt.expectedErr)
+	}
+	if tt.expectIOErr && len(handled) == 0 {
+		t.Errorf("#%v. Glob(%#q, WithErrorHandler) did not call the error handler, but should", idx, tt.pattern)
+	}
+
+	// an error handler that returns the error should act like WithFailOnIOErrors
+	errAbort := errors.New("abort")
+	_, err = Glob(fsys, tt.pattern, WithErrorHandler(func(p string, e error) error {
+		return errAbort
+	}))
+	if tt.expectIOErr && err != errAbort {
+		t.Errorf("#%v. Glob(%#q, WithErrorHandler) has error %v, but should be %v", idx, tt.pattern, err, errAbort)
+	} else if !tt.expectIOErr && err != tt.expectedErr {
+		t.Errorf("#%v. Glob(%#q, WithErrorHandler) has error %v, but should be %v", idx, tt.pattern, err, tt.expectedErr)
+	}
+}
+
+func TestErrorHandlerSkipDir(t *testing.T) {
+	if onWindows {
+		// broken symlinks won't work on Windows
+		return
+	}
+
+	fsys := os.DirFS("test")
+	handler := WithErrorHandler(func(p string, err error) error {
+		return SkipDir
+	})
+
+	matches, err := Glob(fsys, "**", handler)
+	if err != nil {
+		t.Errorf("Glob(`**`, WithErrorHandler) has error %v, but should not", err)
+	}
+	if inSlice("broken-symlink", matches) {
+		t.Errorf("Glob(`**`, WithErrorHandler) = %#v - contains broken-symlink, but shouldn't", matches)
+	}
+
+	matches = nil
+	err = GlobWalk(fsys, "**", func(p string, d fs.DirEntry) error {
+		matches = append(matches, p)
+		return nil
+	}, handler)
+	if err != nil {
+		t.Errorf("GlobWalk(`**`, WithErrorHandler) has error %v, but should not", err)
+	}
+	if inSlice("broken-symlink", matches) {
+		t.Errorf("GlobWalk(`**`, WithErrorHandler) = %#v - contains broken-symlink, but shouldn't", matches)
+	}
+}
+
+func TestGlobWithCollectIOErrors(t *testing.T) {
+	fsys := os.DirFS("test")
+	for idx, tt := range matchTests {
+		if tt.testOnDisk {
+			testGlobWithCollectIOErrorsWith(t, idx, tt, fsys)
+		}
+	}
+}
+
+func testGlobWithCollectIOErrorsWith(t *testing.T, idx int, tt MatchTest, fsys fs.FS) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) panicked: %#v", idx, tt.pattern, r)
+		}
+	}()
+
+	numResults := tt.numResults
+	if onWindows {
+		numResults = tt.winNumResults
+	}
+
+	matches, err := Glob(fsys, tt.pattern, WithCollectIOErrors())
+	if len(matches) != numResults {
+		t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) = %#v - should have %#v results, got %#v", idx, tt.pattern, matches, numResults, len(matches))
+	}
+	if tt.expectedErr != nil {
+		if err != tt.expectedErr {
+			t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) has error %v, but should be %v", idx, tt.pattern, err, tt.expectedErr)
+		}
+		return
+	}
+	if tt.expectIOErr && err == nil {
+		t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) does not have an error, but should", idx, tt.pattern)
+	}
+	if err == nil {
+		return
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) has error %v, which is not a joined error", idx, tt.pattern, err)
+		return
+	}
+	for _, e := range joined.Unwrap() {
+		var ioErr *GlobIOError
+		if !errors.As(e, &ioErr) || ioErr.Path == "" || (ioErr.Op != "stat" && ioErr.Op != "readdir") {
+			t.Errorf("#%v. Glob(%#q, WithCollectIOErrors) has error %#v, which is not a valid *GlobIOError", idx, tt.pattern, e)
+		}
+	}
+}
+
+func TestCollectIOErrorsBrokenSymlink(t *testing.T) {
+	if onWindows {
+		// broken symlinks won't work on Windows
+		return
+	}
+
+	fsys := os.DirFS("test")
+	var matches []string
+	err := GlobWalk(fsys, "**", func(p string, d fs.DirEntry) error {
+		matches = append(matches, p)
+		return nil
+	}, WithCollectIOErrors())
+	if !inSlice("broken-symlink", matches) {
+		t.Errorf("GlobWalk(`**`, WithCollectIOErrors) = %#v - doesn't contain broken-symlink, but should", matches)
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 1 {
+		t.Errorf("GlobWalk(`**`, WithCollectIOErrors) has error %v, but should have exactly one *GlobIOError", err)
+		return
+	}
+	ioErr, ok := joined.Unwrap()[0].(*GlobIOError)
+	if !ok || ioErr.Path != "broken-symlink" || ioErr.Op != "stat" || !errors.Is(ioErr, fs.ErrNotExist) {
+		t.Errorf("GlobWalk(`**`, WithCollectIOErrors) has error %#v, but should be a stat error for broken-symlink", joined.Unwrap()[0])
+	}
+}
+
 func TestFilepathGlob(t *testing.T) {
 	doFilepathGlobTest(t)
 }
@@ -467,6 +625,162 @@ func testFilepathGlobWith(t *testing.T, idx int, tt MatchTest, g *glob, opts []G
 	}
 }
 
+func TestFilepathGlobWalk(t *testing.T) {
+	doFilepathGlobWalkTest(t)
+}
+
+func TestFilepathGlobWalkWithFailOnIOErrors(t *testing.T) {
+	doFilepathGlobWalkTest(t, WithFailOnIOErrors())
+}
+
+func doFilepathGlobWalkTest(t *testing.T, opts ...GlobOption) {
+	glob := newGlob(opts...)
+	fsys := os.DirFS("test")
+
+	// The patterns are relative to the "test" sub-directory.
+	defer func() {
+		os.Chdir("..")
+	}()
+	os.Chdir("test")
+
+	for idx, tt := range matchTests {
+		if tt.testOnDisk {
+			ttmod := tt
+			ttmod.pattern = filepath.FromSlash(tt.pattern)
+			ttmod.testPath = filepath.FromSlash(tt.testPath)
+			testFilepathGlobWalkWith(t, idx, ttmod, glob, opts, fsys)
+		}
+	}
+}
+
+func testFilepathGlobWalkWith(t *testing.T, idx int, tt MatchTest, g *glob, opts []GlobOption, fsys fs.FS) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. FilepathGlobWalk(%#q, %#v) panicked: %#v", idx, tt.pattern, opts, r)
+		}
+	}()
+
+	var matches []string
+	err := FilepathGlobWalk(tt.pattern, func(p string, d fs.DirEntry) error {
+		matches = append(matches, p)
+		return nil
+	}, opts...)
+	verifyGlobResults(t, idx, "FilepathGlobWalk", tt, g, fsys, matches, err)
+
+	if tt.isStandard && len(opts) == 0 {
+		stdMatches, stdErr := filepath.Glob(tt.pattern)
+		if !compareSlices(matches, stdMatches) || !compareErrors(err, stdErr) {
+			t.Errorf("#%v. FilepathGlobWalk(%#q, %#v) != filepath.Glob(...). Got %#v, %v want %#v, %v", idx, tt.pattern, opts, matches, err, stdMatches, stdErr)
+		}
+	}
+}
+
+type SplitPatternAllTest struct {
+	pattern  string  // pattern to split
+	expected []Split // expected splits
+}
+
+var splitPatternAllTests = []SplitPatternAllTest{
+	{"meta*/**", []Split{{".", "meta*/**"}}},
+	{"../../path/to/meta*/**", []Split{{"../../path/to", "meta*/**"}}},
+	{"/path/*", []Split{{"/path", "*"}}},
+	{"/*", []Split{{"/", "*"}}},
+	{"{src,lib}/pkg/**/*.go", []Split{{"src/pkg", "**/*.go"}, {"lib/pkg", "**/*.go"}}},
+	{"{/etc,/opt}/app/*.conf", []Split{{"/etc/app", "*.conf"}, {"/opt/app", "*.conf"}}},
+	{"a/{b,c/{d,e}}/f*", []Split{{"a/b", "f*"}, {"a/c/d", "f*"}, {"a/c/e", "f*"}}},
+	{"{a,a}/b*", []Split{{"a", "b*"}}},
+	{"{a,b*}/c", []Split{{"a", "c"}, {".", "b*/c"}}},
+	{"a/*/{b,c}", []Split{{"a", "*/{b,c}"}}},
+	{"a/\\{b,c}/*", []Split{{"a/\\{b,c}", "*"}}},
+}
+
+func TestSplitPatternAll(t *testing.T) {
+	for idx, tt := range splitPatternAllTests {
+		splits := SplitPatternAll(tt.pattern)
+		if len(splits) != len(tt.expected) {
+			t.Errorf("#%v. SplitPatternAll(%#q) = %#v - should be %#v", idx, tt.pattern, splits, tt.expected)
+			continue
+		}
+		for i := range splits {
+			if splits[i] != tt.expected[i] {
+				t.Errorf("#%v. SplitPatternAll(%#q) = %#v - should be %#v", idx, tt.pattern, splits, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+var quoteMetaTests = []string{
+	"",
+	"abc",
+	"a*b",
+	"a?b",
+	"[a-z]",
+	"[!a]",
+	"{a,b}",
+	"a,b",
+	"a]b",
+	"a}b",
+	"a\\b",
+	"\\",
+	"**/*.txt",
+	"path/to/[dir]/{x}",
+	"【test】*",
+}
+
+func TestQuoteMeta(t *testing.T) {
+	for idx, s := range quoteMetaTests {
+		quoted := QuoteMeta(s)
+		if unquoted := Unescape(quoted); unquoted != s {
+			t.Errorf("#%v. Unescape(QuoteMeta(%#q)) = %#q, but should be %#q", idx, s, unquoted, s)
+		}
+		if !ValidatePattern(quoted) {
+			t.Errorf("#%v. QuoteMeta(%#q) = %#q, which is not a valid pattern", idx, s, quoted)
+		}
+		if ok, err := Match(quoted, s); !ok || err != nil {
+			t.Errorf("#%v. Match(QuoteMeta(%#q), %#q) = %v, %v want true, nil", idx, s, s, ok, err)
+		}
+		if ok, err := Match(quoted, s+"x"); ok || err != nil {
+			t.Errorf("#%v. Match(QuoteMeta(%#q), %#q) = %v, %v want false, nil", idx, s, s+"x", ok, err)
+		}
+	}
+}
+
+func TestPathQuoteMetaFake(t *testing.T) {
+	// This test fakes that our path separator is `\\` so we can test what it
+	// would be like on Windows.
+	for idx, s := range quoteMetaTests {
+		quoted := doQuoteMeta(s, '\\')
+		if !doValidatePattern(quoted, '\\') {
+			t.Errorf("#%v. PathQuoteMeta(%#q) = %#q, which is not a valid pattern", idx, s, quoted)
+		}
+		if ok, err := matchWithSeparator(quoted, s, '\\', true); !ok || err != nil {
+			t.Errorf("#%v. PathMatch(PathQuoteMeta(%#q), %#q) = %v, %v want true, nil", idx, s, s, ok, err)
+		}
+		if ok, err := matchWithSeparator(quoted, s+"x", '\\', true); ok || err != nil {
+			t.Errorf("#%v. PathMatch(PathQuoteMeta(%#q), %#q) = %v, %v want false, nil", idx, s, s+"x", ok, err)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		pattern, expected string
+	}{
+		{"abc", "abc"},
+		{"a\\*b", "a*b"},
+		{"a\\\\b", "a\\b"},
+		{"\\a\\,\\]", "a,]"},
+		{"a*b", "a*b"},
+		{"a\\", "a\\"},
+	}
+	for idx, tt := range tests {
+		if unescaped := Unescape(tt.pattern); unescaped != tt.expected {
+			t.Errorf("#%v. Unescape(%#q) = %#q, but should be %#q", idx, tt.pattern, unescaped, tt.expected)
+		}
+	}
+}
+
 func verifyGlobResults(t *testing.T, idx int, fn string, tt MatchTest, g *glob, fsys fs.FS, matches []string, err error) {
 	if g.failOnIOErrors {
 		if tt.expectIOErr && err == nil {
@@ -517,6 +831,175 @@ func TestGlobSorted(t *testing.T) {
 	}
 }
 
+func TestGlobWithLimit(t *testing.T) {
+	fsys := os.DirFS("test")
+	for idx, tt := range matchTests {
+		if tt.testOnDisk && tt.expectedErr == nil {
+			testGlobWithLimitWith(t, idx, tt, fsys, 2)
+		}
+	}
+}
+
+func testGlobWithLimitWith(t *testing.T, idx int, tt MatchTest, fsys fs.FS, limit int) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. Glob(%#q, WithLimit(%v)) panicked: %#v", idx, tt.pattern, limit, r)
+		}
+	}()
+
+	expected := tt.numResults
+	if onWindows {
+		expected = tt.winNumResults
+	}
+	if expected > limit {
+		expected = limit
+	}
+
+	all, _ := Glob(fsys, tt.pattern)
+	matches, err := Glob(fsys, tt.pattern, WithLimit(limit))
+	if err != nil {
+		t.Errorf("#%v. Glob(%#q, WithLimit(%v)) has error %v, but should not", idx, tt.pattern, limit, err)
+	}
+	if len(matches) != expected {
+		t.Errorf("#%v. Glob(%#q, WithLimit(%v)) = %#v - should have %#v results, got %#v", idx, tt.pattern, limit, matches, expected, len(matches))
+	}
+	for _, m := range matches {
+		if !inSlice(m, all) {
+			t.Errorf("#%v. Glob(%#q, WithLimit(%v)) = %#v - contains %v, but shouldn't", idx, tt.pattern, limit, matches, m)
+		}
+	}
+
+	var walked []string
+	err = GlobWalk(fsys, tt.pattern, func(p string, d fs.DirEntry) error {
+		walked = append(walked, p)
+		return nil
+	}, WithLimit(limit))
+	if err != nil {
+		t.Errorf("#%v. GlobWalk(%#q, WithLimit(%v)) has error %v, but should not", idx, tt.pattern, limit, err)
+	}
+	if len(walked) != expected {
+		t.Errorf("#%v. GlobWalk(%#q, WithLimit(%v)) = %#v - should have %#v results, got %#v", idx, tt.pattern, limit, walked, expected, len(walked))
+	}
+}
+
+func TestGlobWalkWithLimitSkipDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/x.txt": {},
+		"b/y.txt": {},
+		"c/z.txt": {},
+	}
+
+	calls := 0
+	err := GlobWalk(fsys, "{a,b,c}/*.txt", func(p string, d fs.DirEntry) error {
+		calls++
+		return SkipDir
+	}, WithLimit(1))
+	if err != nil || calls != 1 {
+		t.Errorf("GlobWalk(`{a,b,c}/*.txt`, WithLimit(1)) called fn %v times, with error %v - should be once", calls, err)
+	}
+}
+
+func TestGlobExists(t *testing.T) {
+	fsys := os.DirFS("test")
+	for idx, tt := range matchTests {
+		if tt.testOnDisk {
+			expected := tt.numResults
+			if onWindows {
+				expected = tt.winNumResults
+			}
+
+			exists, err := GlobExists(fsys, tt.pattern)
+			if exists != (expected > 0) || err != tt.expectedErr {
+				t.Errorf("#%v. GlobExists(%#q) = %v, %v want %v, %v", idx, tt.pattern, exists, err, expected > 0, tt.expectedErr)
+			}
+		}
+	}
+}
+
+func TestGlobWithStats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a/b.go":   {},
+		"a/c.txt":  {},
+		"a/d/e.go": {},
+		"x.go":     {},
+	}
+
+	var stats GlobStats
+	matches, err := Glob(fsys, "a/*.go", WithStats(&stats))
+	if err != nil || len(matches) != 1 {
+		t.Errorf("Glob(`a/*.go`, WithStats) = %#v, %v - should have 1 result", matches, err)
+	}
+	stats.Duration = 0
+	expected := GlobStats{ReadDirCalls: 1, EntriesExamined: 3, MatchAttempts: 3, Matches: 1}
+	if stats != expected {
+		t.Errorf("Glob(`a/*.go`, WithStats) stats = %+v - should be %+v", stats, expected)
+	}
+
+	// counts are added up across calls
+	err = GlobWalk(fsys, "*/*.go", func(p string, d fs.DirEntry) error {
+		return nil
+	}, WithStats(&stats))
+	if err != nil {
+		t.Errorf("GlobWalk(`*/*.go`, WithStats) has error %v, but should not", err)
+	}
+	stats.Duration = 0
+	expected = GlobStats{ReadDirCalls: 3, EntriesExamined: 8, MatchAttempts: 7, Matches: 2}
+	if stats != expected {
+		t.Errorf("GlobWalk(`*/*.go`, WithStats) stats = %+v - should be %+v", stats, expected)
+	}
+
+	stats = GlobStats{}
+	matches, err = Glob(fsys, "{a,x}.go", WithStats(&stats))
+	if err != nil || len(matches) != 1 {
+		t.Errorf("Glob(`{a,x}.go`, WithStats) = %#v, %v - should have 1 result", matches, err)
+	}
+	stats.Duration = 0
+	// `a.go` doesn't exist, which is an ignored I/O error
+	expected = GlobStats{StatCalls: 2, Matches: 1, ErrorsIgnored: 1}
+	if stats != expected {
+		t.Errorf("Glob(`{a,x}.go`, WithStats) stats = %+v - should be %+v", stats, expected)
+	}
+}
+
+func TestGlobWithStatsOnDisk(t *testing.T) {
+	fsys := os.DirFS("test")
+	for idx, tt := range matchTests {
+		if !tt.testOnDisk || tt.expectedErr != nil {
+			continue
+		}
+
+		var stats GlobStats
+		matches, _ := Glob(fsys, tt.pattern, WithStats(&stats))
+		if stats.Matches != len(matches) {
+			t.Errorf("#%v. Glob(%#q, WithStats) has %v results, but stats.Matches = %v", idx, tt.pattern, len(matches), stats.Matches)
+		}
+		if stats.MatchAttempts > stats.EntriesExamined {
+			t.Errorf("#%v. Glob(%#q, WithStats) stats = %+v - more match attempts than entries", idx, tt.pattern, stats)
+		}
+
+		walked := 0
+		stats = GlobStats{}
+		GlobWalk(fsys, tt.pattern, func(p string, d fs.DirEntry) error {
+			walked++
+			return nil
+		}, WithStats(&stats))
+		if stats.Matches != walked {
+			t.Errorf("#%v. GlobWalk(%#q, WithStats) walked %v results, but stats.Matches = %v", idx, tt.pattern, walked, stats.Matches)
+		}
+	}
+
+	if onWindows {
+		// broken symlinks won't work on Windows
+		return
+	}
+
+	var stats GlobStats
+	Glob(fsys, "**", WithStats(&stats))
+	if stats.SymlinksResolved != 3 || stats.ErrorsIgnored != 1 {
+		t.Errorf("Glob(`**`, WithStats) stats = %+v - should have resolved 3 symlinks and ignored 1 error", stats)
+	}
+}
+
 func BenchmarkGlob(b *testing.B) {
 	fsys := os.DirFS("test")
 	b.ReportAllocs()
@@ -0,0 +1,246 @@
+package doublestar
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+// The characters used for the parts of example names that aren't fixed by
+// the pattern, such as what a `*` matches.
+const exampleChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// Examples generates up to `n` distinct names that match the pattern, for
+// use in test fixtures, documentation, or property tests. The examples cycle
+// through each alternative of each alternation, each range of each character
+// class, and zero, one, or two directories for each `**`, so the first few
+// examples show off most of what the pattern can match. Parts of the names
+// that aren't fixed by the pattern, such as what a `*` matches, are chosen
+// using `rnd`. If `rnd` is nil, a fixed seed is used so the results are
+// reproducible.
+//
+// Every example is checked with Match(), so fewer than `n` examples may be
+// returned if the pattern doesn't match many names (for example, `a/b` only
+// matches itself).
+//
+// Examples assumes the pattern uses `/` as the path separator. The only
+// possible returned error is ErrBadPattern, when pattern is malformed.
+//
+func Examples(pattern string, n int, rnd *rand.Rand) ([]string, error) {
+	root, err := syntax.Parse(pattern)
+	if err != nil {
+		return nil, ErrBadPattern
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+
+	examples := make([]string, 0, n)
+	seen := make(map[string]bool)
+	for i := 0; len(examples) < n && i < 10*n+10; i++ {
+		gen := &exampleGenerator{rnd: rnd, round: i}
+		gen.sequence(root)
+		name := gen.b.String()
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if ok, _ := Match(pattern, name); ok {
+			examples = append(examples, name)
+		}
+	}
+	return examples, nil
+}
+
+// Counterexamples generates up to `n` distinct names that are "near misses"
+// for the pattern: names that are similar to names the pattern matches, but
+// which the pattern does not match. They are generated by making small
+// changes to examples from Examples(), such as removing, adding, or changing
+// a character or path segment. `rnd` is used the same way as in Examples().
+//
+// Every counterexample is checked with Match(), so fewer than `n` may be
+// returned if the pattern matches most names, or none at all if it matches
+// every name (for example, `**`).
+//
+// Counterexamples assumes the pattern uses `/` as the path separator. The only
+// possible returned error is ErrBadPattern, when pattern is malformed.
+//
+func Counterexamples(pattern string, n int, rnd *rand.Rand) ([]string, error) {
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+	examples, err := Examples(pattern, n, rnd)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	if len(examples) == 0 {
+		examples = []string{"a", "a/b"}
+	}
+
+	counterexamples := make([]string, 0, n)
+	seen := make(map[string]bool)
+	for i := 0; len(counterexamples) < n && i < 20*n+20; i++ {
+		name := mutateExample(examples[i%len(examples)], i, rnd)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if ok, _ := Match(pattern, name); !ok {
+			counterexamples = append(counterexamples, name)
+		}
+	}
+	return counterexamples, nil
+}
+
+// Makes a small change to a name. The kind of change cycles with `round`.
+func mutateExample(name string, round int, rnd *rand.Rand) string {
+	runes := []rune(name)
+	c := rune(exampleChars[rnd.Intn(len(exampleChars))])
+	switch round % 6 {
+	case 0:
+		// change a character
+		if len(runes) > 0 {
+			i := rnd.Intn(len(runes))
+			if runes[i] == c {
+				c = '_'
+			}
+			runes[i] = c
+		}
+
+	case 1:
+		// remove a character
+		if len(runes) > 0 {
+			i := rnd.Intn(len(runes))
+			runes = append(runes[:i], runes[i+1:]...)
+		}
+
+	case 2:
+		// add a character
+		i := rnd.Intn(len(runes) + 1)
+		runes = append(runes[:i], append([]rune{c}, runes[i:]...)...)
+
+	case 3:
+		// add a path segment at the end
+		return name + "/" + string(c)
+
+	case 4:
+		// remove the last path segment
+		if i := strings.LastIndexByte(name, '/'); i > 0 {
+			return name[:i]
+		}
+		return string(c) + "/" + name
+
+	case 5:
+		// add a path segment at the beginning
+		return string(c) + "/" + name
+	}
+	return string(runes)
+}
+
+// Generates one example name from a syntax tree. `round` is used to cycle
+// through each alternative, class range, and doublestar depth.
+type exampleGenerator struct {
+	b     strings.Builder
+	rnd   *rand.Rand
+	round int
+}
+
+func (g *exampleGenerator) sequence(seq *syntax.Node) {
+	for i := 0; i < len(seq.Children); i++ {
+		n := seq.Children[i]
+		switch n.Kind {
+		case syntax.Literal:
+			g.b.WriteString(n.Text)
+
+		case syntax.Separator:
+			g.b.WriteByte('/')
+
+		case syntax.Star:
+			g.randomString(g.rnd.Intn(4))
+
+		case syntax.DoubleStar:
+			var prev, next *syntax.Node
+			if i > 0 {
+				prev = seq.Children[i-1]
+			}
+			if i+1 < len(seq.Children) {
+				next = seq.Children[i+1]
+			}
+			if isInSegment(prev) || isInSegment(next) {
+				// a mid-segment `**` is just a `*`
+				g.randomString(g.rnd.Intn(4))
+				continue
+			}
+
+			depth := g.pick(3)
+			for d := 0; d < depth; d++ {
+				if d > 0 {
+					g.b.WriteByte('/')
+				}
+				g.randomString(1 + g.rnd.Intn(3))
+			}
+			if depth == 0 && next != nil && next.Kind == syntax.Separator {
+				// zero directories: `a/**/b` matches `a/b`
+				i++
+			}
+
+		case syntax.Any:
+			g.randomString(1)
+
+		case syntax.Class:
+			g.class(n)
+
+		case syntax.Alternation:
+			g.sequence(n.Children[g.pick(len(n.Children))])
+		}
+	}
+}
+
+// Picks one of `k` choices: the first `k` rounds pick each choice in turn,
+// and later rounds pick randomly, so that combinations of choices are
+// covered, too.
+func (g *exampleGenerator) pick(k int) int {
+	if g.round < k {
+		return g.round
+	}
+	return g.rnd.Intn(k)
+}
+
+// Writes `l` random characters.
+func (g *exampleGenerator) randomString(l int) {
+	for i := 0; i < l; i++ {
+		g.b.WriteByte(exampleChars[g.rnd.Intn(len(exampleChars))])
+	}
+}
+
+// Writes a character that matches the class, cycling through its ranges.
+func (g *exampleGenerator) class(n *syntax.Node) {
+	if n.Negated {
+		node := &automatonNode{kind: anClass, negated: true, ranges: n.Ranges}
+		for tries := 0; tries < 10; tries++ {
+			c := rune(exampleChars[g.rnd.Intn(len(exampleChars))])
+			if classMatches(node, c) {
+				g.b.WriteRune(c)
+				return
+			}
+		}
+		g.b.WriteRune('_')
+		return
+	}
+
+	r := n.Ranges[g.pick(len(n.Ranges))]
+	c := r.Lo
+	if r.Hi > r.Lo {
+		c += rune(g.rnd.Int63n(int64(r.Hi-r.Lo) + 1))
+	}
+	g.b.WriteRune(c)
+}
@@ -22,6 +22,9 @@ import (
 // will not work because io/fs will reject them. If they appear _before_ any
 // meta characters _and_ before a `/`, the `splitPattern` function below will
 // take care of them correctly.
+//
+// For a more complete tool, with flags for excludes, output formats, and so
+// on, see cmd/dstar.
 
 func main() {
 	pattern := os.Args[1]
@@ -32,11 +35,11 @@ func main() {
 	fsys := os.DirFS(basepath)
 	matches, err := doublestar.Glob(fsys, pattern)
 	if err != nil {
-		fmt.Printf("Error: %v", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf(strings.Join(matches, "\n"))
+	fmt.Print(strings.Join(matches, "\n"))
 	fmt.Print("\n\n")
 	fmt.Printf("Found %d items.\n", len(matches))
 }
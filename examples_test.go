@@ -0,0 +1,96 @@
+package doublestar
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	for idx, tt := range matchTests {
+		if tt.expectedErr != nil {
+			continue
+		}
+
+		rnd := rand.New(rand.NewSource(int64(idx)))
+		examples, err := Examples(tt.pattern, 5, rnd)
+		if err != nil {
+			t.Errorf("#%v. Examples(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+		if len(examples) > 5 {
+			t.Errorf("#%v. Examples(%#q) = %#q - should have at most 5 examples", idx, tt.pattern, examples)
+		}
+		seen := make(map[string]bool)
+		for _, name := range examples {
+			if ok, _ := Match(tt.pattern, name); !ok {
+				t.Errorf("#%v. Examples(%#q) returned %#q, which it doesn't match", idx, tt.pattern, name)
+			}
+			if seen[name] {
+				t.Errorf("#%v. Examples(%#q) returned %#q more than once", idx, tt.pattern, name)
+			}
+			seen[name] = true
+		}
+
+		counterexamples, err := Counterexamples(tt.pattern, 5, rnd)
+		if err != nil {
+			t.Errorf("#%v. Counterexamples(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+		for _, name := range counterexamples {
+			if ok, _ := Match(tt.pattern, name); ok {
+				t.Errorf("#%v. Counterexamples(%#q) returned %#q, which it matches", idx, tt.pattern, name)
+			}
+		}
+	}
+}
+
+func TestExamplesCoverage(t *testing.T) {
+	examples, _ := Examples("{a,b,c}.txt", 3, nil)
+	if expected := []string{"a.txt", "b.txt", "c.txt"}; !reflect.DeepEqual(examples, expected) {
+		t.Errorf("Examples(`{a,b,c}.txt`) = %#q - should be %#q", examples, expected)
+	}
+
+	examples, _ = Examples("[x][0-0][a-a]", 2, nil)
+	if expected := []string{"x0a"}; !reflect.DeepEqual(examples, expected) {
+		t.Errorf("Examples(`[x][0-0][a-a]`) = %#q - should be %#q", examples, expected)
+	}
+
+	// the first three examples should have zero, one, and two directories
+	examples, _ = Examples("a/**/b", 3, nil)
+	if len(examples) != 3 {
+		t.Fatalf("Examples(`a/**/b`) = %#q - should have 3 examples", examples)
+	}
+	for i, name := range examples {
+		if depth := strings.Count(name, "/") - 1; depth != i {
+			t.Errorf("Examples(`a/**/b`)[%v] = %#q - should have %v directories", i, name, i)
+		}
+	}
+}
+
+func TestExamplesReproducible(t *testing.T) {
+	a, _ := Examples("src/**/*.{go,txt}", 10, nil)
+	b, _ := Examples("src/**/*.{go,txt}", 10, nil)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Examples() with a nil rnd returned %#q, then %#q - should be the same", a, b)
+	}
+}
+
+func TestExamplesFew(t *testing.T) {
+	if examples, err := Examples("a/b", 5, nil); err != nil || !reflect.DeepEqual(examples, []string{"a/b"}) {
+		t.Errorf("Examples(`a/b`) = %#q, %v - should only be `a/b`", examples, err)
+	}
+	if counterexamples, err := Counterexamples("**", 5, nil); err != nil || len(counterexamples) != 0 {
+		t.Errorf("Counterexamples(`**`) = %#q, %v - should be empty", counterexamples, err)
+	}
+	if counterexamples, err := Counterexamples("a/*.go", 5, nil); err != nil || len(counterexamples) != 5 {
+		t.Errorf("Counterexamples(`a/*.go`) = %#q, %v - should have 5 counterexamples", counterexamples, err)
+	}
+	if _, err := Examples("a[", 5, nil); err != ErrBadPattern {
+		t.Errorf("Examples(`a[`) = %v - should be ErrBadPattern", err)
+	}
+	if _, err := Counterexamples("a[", 5, nil); err != ErrBadPattern {
+		t.Errorf("Counterexamples(`a[`) = %v - should be ErrBadPattern", err)
+	}
+}
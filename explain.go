@@ -0,0 +1,344 @@
+package doublestar
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+// Explanation describes how Match() compared a name to a pattern. See
+// Explain().
+type Explanation struct {
+	Pattern string
+	Name    string
+	Matched bool
+
+	// Steps describes how the pattern lined up with the name. If the name
+	// matched, this is the alignment that succeeded. Otherwise, it is the
+	// alignment that got furthest into the name before failing.
+	Steps []ExplainStep
+
+	// If the name did not match, FailNamePos is the byte offset in Name where
+	// the furthest alignment failed, and FailPos and FailEnd are the byte
+	// offsets in Pattern of the part of the pattern that failed to match there.
+	// If the pattern ran out before the name did, FailPos and FailEnd are both
+	// len(Pattern).
+	FailNamePos int
+	FailPos     int
+	FailEnd     int
+}
+
+// ExplainStep describes how one part of a pattern lined up with a name.
+type ExplainStep struct {
+	// Kind is the kind of the part of the pattern. A `**` that isn't the only
+	// thing in its path segment behaves like a `*`, so its Kind is
+	// syntax.Star.
+	Kind syntax.Kind
+
+	// Pos and End are the byte offsets in the pattern of this part of it. For
+	// syntax.DoubleStar, this includes the trailing separator, if any.
+	Pos, End int
+
+	// NamePos and NameEnd are the byte offsets in the name that this part of
+	// the pattern matched.
+	NamePos, NameEnd int
+
+	// For syntax.Alternation, AltPos and AltEnd are the byte offsets in the
+	// pattern of the alternative that was taken.
+	AltPos, AltEnd int
+}
+
+// Explain reports how Match() compared `name` to `pattern`: if the name
+// matches, it shows what each part of the pattern matched, such as what each
+// `*` or `**` consumed, and which alternative of each `{...}` was taken. If it
+// doesn't, it shows how far Match() got into the name before failing, and
+// which part of the pattern failed there. Explanation.String() formats the
+// result for people.
+//
+// Explain assumes the pattern uses `/` as the path separator, like Match().
+// The only possible returned error is ErrBadPattern, when pattern is
+// malformed.
+//
+func Explain(pattern, name string) (*Explanation, error) {
+	if !ValidatePattern(pattern) {
+		return nil, ErrBadPattern
+	}
+
+	tr := &matchTrace{pattern: pattern, failNamePos: -1}
+	matched, err := doMatchWithSeparator(pattern, name, '/', true, -1, -1, -1, -1, 0, 0, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Explanation{Pattern: pattern, Name: name, Matched: matched}
+	if matched {
+		e.Steps = orderSteps(tr.steps, true)
+	} else {
+		e.Steps = orderSteps(tr.failSteps, false)
+		e.FailNamePos = tr.failNamePos
+		e.FailPos = tr.failPos
+		e.FailEnd = patternTokenEnd(pattern, tr.failPos)
+	}
+	return e, nil
+}
+
+// String formats the explanation with one line per step. For example:
+//
+//   `src/**/*.go` matches `src/a/b.go`:
+//     `src/` matched `src/`
+//     `**/` matched `a/`
+//     `*` matched `b`
+//     `.go` matched `.go`
+//
+func (e *Explanation) String() string {
+	var b strings.Builder
+	if e.Matched {
+		fmt.Fprintf(&b, "%#q matches %#q:\n", e.Pattern, e.Name)
+	} else {
+		fmt.Fprintf(&b, "%#q does not match %#q:\n", e.Pattern, e.Name)
+	}
+
+	for i := 0; i < len(e.Steps); i++ {
+		step := e.Steps[i]
+		end, nameEnd := step.End, step.NameEnd
+		if step.Kind == syntax.Literal || step.Kind == syntax.Separator {
+			// combine runs of literals and separators into one line
+			for ; i+1 < len(e.Steps); i++ {
+				next := e.Steps[i+1]
+				if (next.Kind != syntax.Literal && next.Kind != syntax.Separator) || next.Pos != end || next.NamePos != nameEnd {
+					break
+				}
+				end, nameEnd = next.End, next.NameEnd
+			}
+		}
+
+		fmt.Fprintf(&b, "  %#q matched %#q", e.Pattern[step.Pos:end], e.Name[step.NamePos:nameEnd])
+		switch step.Kind {
+		case syntax.Alternation:
+			fmt.Fprintf(&b, " (taking alternative %#q)", e.Pattern[step.AltPos:step.AltEnd])
+		case syntax.DoubleStar:
+			if step.NamePos == step.NameEnd {
+				b.WriteString(" (zero directories)")
+			}
+		}
+		b.WriteByte('\n')
+	}
+
+	if !e.Matched {
+		rest := e.Name[e.FailNamePos:]
+		switch {
+		case e.FailPos == len(e.Pattern):
+			fmt.Fprintf(&b, "  the pattern ended, but the name still has %#q\n", rest)
+		case rest == "":
+			fmt.Fprintf(&b, "  the name ended, but %#q does not match an empty string\n", e.Pattern[e.FailPos:])
+		default:
+			fmt.Fprintf(&b, "  %#q did not match %#q\n", e.Pattern[e.FailPos:e.FailEnd], rest)
+		}
+	}
+	return b.String()
+}
+
+// A matchTrace records how doMatchWithSeparator() lines up a pattern with a
+// name. When doMatchWithSeparator() expands an alternation, it matches a new
+// pattern with the alternation replaced by one of its alternatives, so
+// posMap maps positions in that pattern back to the original.
+type matchTrace struct {
+	pattern        string
+	posMap         []int
+	steps          []ExplainStep
+	starStep       int
+	doublestarStep int
+	tokenStart     int
+
+	// the alignment that got furthest into the name
+	failNamePos int
+	failPos     int
+	failSteps   []ExplainStep
+}
+
+// The state of a matchTrace before an alternative is tried, so it can be
+// restored if the alternative fails.
+type matchTraceState struct {
+	steps          int
+	posMap         []int
+	starStep       int
+	doublestarStep int
+}
+
+// Maps a position in the pattern being matched to the original pattern.
+func (tr *matchTrace) pos(i int) int {
+	if tr.posMap == nil {
+		return i
+	}
+	if i >= len(tr.posMap) {
+		return len(tr.pattern)
+	}
+	return tr.posMap[i]
+}
+
+// Maps the range [start, end) in the pattern being matched to the original.
+func (tr *matchTrace) span(start, end int) (int, int) {
+	if end <= start {
+		return tr.pos(start), tr.pos(start)
+	}
+	return tr.pos(start), tr.pos(end-1) + 1
+}
+
+// Records that the pattern, from tokenStart to patEnd, matched the name from
+// nameStart to nameEnd. Returns the index of the new step.
+func (tr *matchTrace) add(kind syntax.Kind, patEnd, nameStart, nameEnd int) int {
+	pos, end := tr.span(tr.tokenStart, patEnd)
+	tr.steps = append(tr.steps, ExplainStep{Kind: kind, Pos: pos, End: end, NamePos: nameStart, NameEnd: nameEnd})
+	return len(tr.steps) - 1
+}
+
+// Records that a `*` or `**` step is now matching up to nameEnd, so any
+// steps after it need to be matched again. Alternations are kept, since
+// doMatchWithSeparator() does not go back to alternations it has expanded.
+func (tr *matchTrace) backtrack(step, nameEnd int) {
+	steps := tr.steps[:step+1]
+	for _, s := range tr.steps[step+1:] {
+		if s.Kind == syntax.Alternation {
+			steps = append(steps, s)
+		}
+	}
+	tr.steps = steps
+	tr.steps[step].NameEnd = nameEnd
+}
+
+// Records that the pattern at patIdx failed to match the name at nameIdx.
+func (tr *matchTrace) fail(patIdx, nameIdx int) {
+	if nameIdx > tr.failNamePos {
+		tr.failNamePos = nameIdx
+		tr.failPos = tr.pos(patIdx)
+		tr.failSteps = append(tr.failSteps[:0], tr.steps...)
+	}
+}
+
+// Records that doMatchWithSeparator() is about to try the alternative from
+// altStart to altEnd of the alternation from beforeIdx to closingIdx.
+func (tr *matchTrace) enterAlt(pattern string, beforeIdx, altStart, altEnd, closingIdx int) matchTraceState {
+	saved := matchTraceState{
+		steps:          len(tr.steps),
+		posMap:         tr.posMap,
+		starStep:       tr.starStep,
+		doublestarStep: tr.doublestarStep,
+	}
+
+	step := ExplainStep{Kind: syntax.Alternation}
+	step.Pos, step.End = tr.span(beforeIdx, closingIdx+1)
+	step.AltPos, step.AltEnd = tr.span(altStart, altEnd)
+	tr.steps = append(tr.steps, step)
+
+	posMap := make([]int, 0, beforeIdx+altEnd-altStart+len(pattern)-closingIdx-1)
+	for i := 0; i < beforeIdx; i++ {
+		posMap = append(posMap, tr.pos(i))
+	}
+	for i := altStart; i < altEnd; i++ {
+		posMap = append(posMap, tr.pos(i))
+	}
+	for i := closingIdx + 1; i < len(pattern); i++ {
+		posMap = append(posMap, tr.pos(i))
+	}
+	tr.posMap = posMap
+	return saved
+}
+
+// Restores the state saved by enterAlt().
+func (tr *matchTrace) restore(saved matchTraceState) {
+	tr.steps = tr.steps[:saved.steps]
+	tr.posMap = saved.posMap
+	tr.starStep = saved.starStep
+	tr.doublestarStep = saved.doublestarStep
+}
+
+// Sorts steps into the order they appear in the pattern, and works out what
+// part of the name each alternation matched, which is everything its
+// alternative matched. If the match failed, alternations that nothing was
+// matched in or after are dropped, since Match() may have backtracked before
+// the alternation.
+func orderSteps(steps []ExplainStep, matched bool) []ExplainStep {
+	var ordered, alts []ExplainStep
+	for _, s := range steps {
+		if s.Kind == syntax.Alternation {
+			alts = append(alts, s)
+		} else {
+			ordered = append(ordered, s)
+		}
+	}
+
+	for _, alt := range alts {
+		// steps are in pattern order, so insert the alternation before the
+		// first step inside it
+		i := 0
+		for i < len(ordered) && ordered[i].Pos < alt.Pos {
+			i++
+		}
+		alt.NamePos, alt.NameEnd = -1, -1
+		for j := i; j < len(ordered) && ordered[j].Pos < alt.End; j++ {
+			if alt.NamePos == -1 {
+				alt.NamePos = ordered[j].NamePos
+			}
+			alt.NameEnd = ordered[j].NameEnd
+		}
+		if alt.NamePos == -1 && !matched && i == len(ordered) {
+			continue
+		}
+		if alt.NamePos == -1 {
+			// nothing in the alternative matched anything
+			if i < len(ordered) {
+				alt.NamePos = ordered[i].NamePos
+			} else if i > 0 {
+				alt.NamePos = ordered[i-1].NameEnd
+			} else {
+				alt.NamePos = 0
+			}
+			alt.NameEnd = alt.NamePos
+		}
+
+		ordered = append(ordered, ExplainStep{})
+		copy(ordered[i+1:], ordered[i:])
+		ordered[i] = alt
+	}
+	return ordered
+}
+
+// Returns the end of the part of the pattern that starts at `i`: a character
+// (possibly escaped), a `*` or `**`, a character class, or an alternation.
+func patternTokenEnd(pattern string, i int) int {
+	if i >= len(pattern) {
+		return len(pattern)
+	}
+
+	switch pattern[i] {
+	case '\\':
+		if i+1 < len(pattern) {
+			_, l := utf8.DecodeRuneInString(pattern[i+1:])
+			return i + 1 + l
+		}
+
+	case '*':
+		if i+1 < len(pattern) && pattern[i+1] == '*' {
+			return i + 2
+		}
+
+	case '[':
+		j := i + 1
+		if j < len(pattern) && (pattern[j] == '^' || pattern[j] == '!') {
+			j++
+		}
+		if closing := indexUnescapedByte(pattern[j:], ']', true); closing != -1 {
+			return j + closing + 1
+		}
+
+	case '{':
+		if closing := indexMatchedClosingAlt(pattern[i+1:], true); closing != -1 {
+			return i + closing + 2
+		}
+	}
+
+	_, l := utf8.DecodeRuneInString(pattern[i:])
+	return i + l
+}
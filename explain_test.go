@@ -0,0 +1,113 @@
+package doublestar
+
+import (
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+type ExplainTest struct {
+	pattern, name string
+	expected      string // expected Explanation.String()
+}
+
+var explainTests = []ExplainTest{
+	{"src/**/test_*.py", "src/test_a.py", "`src/**/test_*.py` matches `src/test_a.py`:\n" +
+		"  `src/` matched `src/`\n" +
+		"  `**/` matched `` (zero directories)\n" +
+		"  `test_` matched `test_`\n" +
+		"  `*` matched `a`\n" +
+		"  `.py` matched `.py`\n"},
+	{"src/**/test_*.py", "src/a/b/tst_x.py", "`src/**/test_*.py` does not match `src/a/b/tst_x.py`:\n" +
+		"  `src/` matched `src/`\n" +
+		"  `**/` matched `a/b/`\n" +
+		"  `t` matched `t`\n" +
+		"  `e` did not match `st_x.py`\n"},
+	{"a/{b,c*}/[xy]?", "a/cde/yz", "`a/{b,c*}/[xy]?` matches `a/cde/yz`:\n" +
+		"  `a/` matched `a/`\n" +
+		"  `{b,c*}` matched `cde` (taking alternative `c*`)\n" +
+		"  `c` matched `c`\n" +
+		"  `*` matched `de`\n" +
+		"  `/` matched `/`\n" +
+		"  `[xy]` matched `y`\n" +
+		"  `?` matched `z`\n"},
+	{"a{,b}c", "ac", "`a{,b}c` matches `ac`:\n" +
+		"  `a` matched `a`\n" +
+		"  `{,b}` matched `` (taking alternative ``)\n" +
+		"  `c` matched `c`\n"},
+	{"a/b", "a/b/c", "`a/b` does not match `a/b/c`:\n" +
+		"  `a/b` matched `a/b`\n" +
+		"  the pattern ended, but the name still has `/c`\n"},
+	{"a/**/**", "a", "`a/**/**` does not match `a`:\n" +
+		"  `a` matched `a`\n" +
+		"  the name ended, but `/**/**` does not match an empty string\n"},
+	{"a/[xy]", "a/z", "`a/[xy]` does not match `a/z`:\n" +
+		"  `a/` matched `a/`\n" +
+		"  `[xy]` did not match `z`\n"},
+	{"a/**", "a/b/c", "`a/**` matches `a/b/c`:\n" +
+		"  `a/` matched `a/`\n" +
+		"  `**` matched `b/c`\n"},
+	{"a**b", "axyb", "`a**b` matches `axyb`:\n" +
+		"  `a` matched `a`\n" +
+		"  `**` matched `xy`\n" +
+		"  `b` matched `b`\n"},
+}
+
+func TestExplain(t *testing.T) {
+	for idx, tt := range explainTests {
+		e, err := Explain(tt.pattern, tt.name)
+		if err != nil {
+			t.Errorf("#%v. Explain(%#q, %#q) returned error %v", idx, tt.pattern, tt.name, err)
+			continue
+		}
+		if s := e.String(); s != tt.expected {
+			t.Errorf("#%v. Explain(%#q, %#q) =\n%v\nshould be\n%v", idx, tt.pattern, tt.name, s, tt.expected)
+		}
+	}
+
+	if _, err := Explain("a[", "a"); err != ErrBadPattern {
+		t.Errorf("Explain(`a[`, `a`) = %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestExplainMatchTests(t *testing.T) {
+	for idx, tt := range matchTests {
+		if tt.expectedErr != nil {
+			continue
+		}
+
+		e, err := Explain(tt.pattern, tt.testPath)
+		if err != nil {
+			t.Errorf("#%v. Explain(%#q, %#q) returned error %v", idx, tt.pattern, tt.testPath, err)
+			continue
+		}
+		if e.Matched != tt.shouldMatch {
+			t.Errorf("#%v. Explain(%#q, %#q).Matched = %v - should be %v", idx, tt.pattern, tt.testPath, e.Matched, tt.shouldMatch)
+		}
+
+		// the steps should line up with the pattern and name, in order, and if
+		// the name matched, they should cover all of the name
+		pos, namePos := 0, 0
+		for _, step := range e.Steps {
+			if step.Pos < pos || step.End < step.Pos || step.End > len(tt.pattern) {
+				t.Errorf("#%v. Explain(%#q, %#q) has a step out of order in the pattern: %+v", idx, tt.pattern, tt.testPath, e.Steps)
+				break
+			}
+			if step.NamePos != namePos || step.NameEnd < step.NamePos {
+				t.Errorf("#%v. Explain(%#q, %#q) has a step out of order in the name: %+v", idx, tt.pattern, tt.testPath, e.Steps)
+				break
+			}
+			if step.Kind == syntax.Alternation {
+				pos = step.AltPos
+			} else {
+				pos, namePos = step.End, step.NameEnd
+			}
+		}
+		if e.Matched && namePos != len(tt.testPath) {
+			t.Errorf("#%v. Explain(%#q, %#q) steps don't cover the name: %+v", idx, tt.pattern, tt.testPath, e.Steps)
+		}
+		if !e.Matched && (e.FailNamePos < namePos || e.FailNamePos > len(tt.testPath) || e.FailPos > e.FailEnd) {
+			t.Errorf("#%v. Explain(%#q, %#q) has a bad failure position: %+v", idx, tt.pattern, tt.testPath, e)
+		}
+	}
+}
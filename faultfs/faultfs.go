@@ -0,0 +1,251 @@
+// Package faultfs wraps an fs.FS to inject faults, for testing how code (such
+// as doublestar.Glob() with doublestar.WithFailOnIOErrors()) handles I/O
+// errors that real filesystems rarely produce on demand.
+//
+// Faults are injected on paths matching a doublestar pattern:
+//
+//   fsys := faultfs.New(os.DirFS("testdata")).
+//     Fail("private/**", faultfs.OpAll, fs.ErrPermission).
+//     Fail("**/*.tmp", faultfs.OpStat, fs.ErrNotExist).
+//     Delay("slow/*", faultfs.OpReadDir, 100*time.Millisecond).
+//     Partial("big", 10, errors.New("i/o timeout"))
+//
+// The second rule simulates a race: *.tmp files show up in their directory's
+// listing, but have disappeared by the time they're stat'd.
+package faultfs
+
+import (
+	"io"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Op is a set of operations that a fault applies to.
+type Op uint
+
+const (
+	// OpOpen is fs.FS.Open().
+	OpOpen Op = 1 << iota
+
+	// OpStat is fs.StatFS.Stat(), and Stat() on an open file.
+	OpStat
+
+	// OpReadDir is fs.ReadDirFS.ReadDir(), and ReadDir() on an open directory.
+	OpReadDir
+
+	// OpRead is Read() on an open file.
+	OpRead
+
+	// OpAll is every operation.
+	OpAll = OpOpen | OpStat | OpReadDir | OpRead
+)
+
+var opNames = map[Op]string{
+	OpOpen:    "open",
+	OpStat:    "stat",
+	OpReadDir: "readdir",
+	OpRead:    "read",
+}
+
+// FS wraps an fs.FS and injects faults. See New().
+//
+// Faults may be added at any time, even while the FS is being used by another
+// goroutine. The methods that add faults panic if the pattern is malformed.
+type FS struct {
+	fsys fs.FS
+
+	mu     sync.RWMutex
+	faults []fault
+}
+
+type fault struct {
+	pattern string
+	ops     Op
+	err     error
+	delay   time.Duration
+
+	// for Partial(), the number of entries to return, or -1
+	partial int
+}
+
+// New wraps `fsys`. Until faults are added, the FS behaves exactly like
+// `fsys`.
+func New(fsys fs.FS) *FS {
+	return &FS{fsys: fsys}
+}
+
+// Fail makes the operations `ops` fail with `err` on any path matching
+// `pattern`. The error is wrapped in an *fs.PathError. If several faults match
+// an operation, the first one added wins.
+func (f *FS) Fail(pattern string, ops Op, err error) *FS {
+	return f.add(fault{pattern: pattern, ops: ops, err: err, partial: -1})
+}
+
+// Delay makes the operations `ops` sleep for `d` before running on any path
+// matching `pattern`. The delays of every matching fault are added up.
+func (f *FS) Delay(pattern string, ops Op, d time.Duration) *FS {
+	return f.add(fault{pattern: pattern, ops: ops, delay: d, partial: -1})
+}
+
+// Partial makes ReadDir on any directory matching `pattern` return only its
+// first `n` entries, followed by `err`. If `err` is nil, the rest of the
+// entries are silently dropped, as if they had been deleted.
+func (f *FS) Partial(pattern string, n int, err error) *FS {
+	if n < 0 {
+		n = 0
+	}
+	return f.add(fault{pattern: pattern, ops: OpReadDir, err: err, partial: n})
+}
+
+// Reset removes all of the faults.
+func (f *FS) Reset() *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = nil
+	return f
+}
+
+func (f *FS) add(flt fault) *FS {
+	if !doublestar.ValidatePattern(flt.pattern) {
+		panic("faultfs: bad pattern " + flt.pattern)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.faults = append(f.faults, flt)
+	return f
+}
+
+// Runs the faults that match `op` on `name`: sleeps for any delays, and
+// returns the first error, if any. Partial faults are skipped.
+func (f *FS) inject(op Op, name string) error {
+	f.mu.RLock()
+	var delay time.Duration
+	var err error
+	for _, flt := range f.faults {
+		if flt.ops&op == 0 || !match(flt.pattern, name) {
+			continue
+		}
+		delay += flt.delay
+		if err == nil && flt.partial == -1 {
+			err = flt.err
+		}
+	}
+	f.mu.RUnlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if err != nil {
+		return &fs.PathError{Op: opNames[op], Path: name, Err: err}
+	}
+	return nil
+}
+
+// Returns the first Partial fault that matches `name`, if any.
+func (f *FS) partial(name string) (fault, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, flt := range f.faults {
+		if flt.partial != -1 && match(flt.pattern, name) {
+			return flt, true
+		}
+	}
+	return fault{}, false
+}
+
+func match(pattern, name string) bool {
+	ok, _ := doublestar.Match(pattern, name)
+	return ok
+}
+
+// Open opens the named file, unless a fault says otherwise. Faults for OpRead,
+// OpStat, and OpReadDir also apply to the opened file.
+func (f *FS) Open(name string) (fs.File, error) {
+	if err := f.inject(OpOpen, name); err != nil {
+		return nil, err
+	}
+	file, err := f.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if dir, ok := file.(fs.ReadDirFile); ok {
+		return &openDir{openFile{file, f, name}, dir, 0}, nil
+	}
+	return &openFile{file, f, name}, nil
+}
+
+// Stat returns a FileInfo describing the named file, unless a fault says
+// otherwise.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if err := f.inject(OpStat, name); err != nil {
+		return nil, err
+	}
+	return fs.Stat(f.fsys, name)
+}
+
+// ReadDir reads the named directory, unless a fault says otherwise.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := f.inject(OpReadDir, name); err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if flt, ok := f.partial(name); ok && len(entries) > flt.partial {
+		entries = entries[:flt.partial]
+		if flt.err != nil {
+			err = &fs.PathError{Op: "readdir", Path: name, Err: flt.err}
+		}
+	}
+	return entries, err
+}
+
+type openFile struct {
+	fs.File
+	f    *FS
+	name string
+}
+
+func (o *openFile) Stat() (fs.FileInfo, error) {
+	if err := o.f.inject(OpStat, o.name); err != nil {
+		return nil, err
+	}
+	return o.File.Stat()
+}
+
+func (o *openFile) Read(b []byte) (int, error) {
+	if err := o.f.inject(OpRead, o.name); err != nil {
+		return 0, err
+	}
+	return o.File.Read(b)
+}
+
+type openDir struct {
+	openFile
+	dir fs.ReadDirFile
+
+	// the number of entries read so far, for Partial()
+	read int
+}
+
+func (o *openDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	if err := o.f.inject(OpReadDir, o.name); err != nil {
+		return nil, err
+	}
+	entries, err := o.dir.ReadDir(count)
+	if flt, ok := o.f.partial(o.name); ok && o.read+len(entries) > flt.partial {
+		entries = entries[:flt.partial-o.read]
+		o.read += len(entries)
+		if flt.err != nil {
+			return entries, &fs.PathError{Op: "readdir", Path: o.name, Err: flt.err}
+		}
+		if count > 0 && len(entries) == 0 {
+			return nil, io.EOF
+		}
+		return entries, nil
+	}
+	o.read += len(entries)
+	return entries, err
+}
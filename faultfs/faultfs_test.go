@@ -0,0 +1,131 @@
+package faultfs_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/bmatcuk/doublestar/v4/faultfs"
+)
+
+func newTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"a/b.go":   {Data: []byte("package b")},
+		"a/c.go":   {},
+		"a/d/e.go": {},
+		"f/g.go":   {},
+	}
+}
+
+func TestFS(t *testing.T) {
+	// without any faults, it should behave exactly like the wrapped FS
+	fsys := faultfs.New(newTestFS())
+	if err := fstest.TestFS(fsys, "a/b.go", "a/c.go", "a/d/e.go", "f/g.go"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFail(t *testing.T) {
+	fsys := faultfs.New(newTestFS()).Fail("a/d", faultfs.OpReadDir, fs.ErrPermission)
+
+	// by default, Glob ignores the error
+	matches, err := doublestar.Glob(fsys, "**/*.go")
+	expected := []string{"a/b.go", "a/c.go", "f/g.go"}
+	if err != nil || !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Glob(`**/*.go`) = %#v, %v - should be %#v", matches, err, expected)
+	}
+
+	_, err = doublestar.Glob(fsys, "**/*.go", doublestar.WithFailOnIOErrors())
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Op != "readdir" || pathErr.Path != "a/d" || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Glob(`**/*.go`, WithFailOnIOErrors) has error %v - should be a readdir permission error for a/d", err)
+	}
+
+	err = doublestar.GlobWalk(fsys, "a/*/*.go", func(p string, d fs.DirEntry) error {
+		return nil
+	}, doublestar.WithFailOnIOErrors())
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("GlobWalk(`a/*/*.go`, WithFailOnIOErrors) has error %v - should be fs.ErrPermission", err)
+	}
+
+	// a file that is listed, but disappears before it can be stat'd
+	fsys.Reset().Fail("**/b.go", faultfs.OpStat, fs.ErrNotExist)
+	matches, err = doublestar.Glob(fsys, "a/b.go")
+	if err != nil || len(matches) != 0 {
+		t.Errorf("Glob(`a/b.go`) = %#v, %v - should have no results", matches, err)
+	}
+	if entries, err := fs.ReadDir(fsys, "a"); err != nil || len(entries) != 3 {
+		t.Errorf("ReadDir(`a`) = %v entries, %v - should have 3 entries", len(entries), err)
+	}
+
+	// faults on open apply to fs.ReadFile, and faults on read apply to the
+	// open file
+	fsys.Reset().Fail("a/b.go", faultfs.OpRead, io.ErrUnexpectedEOF)
+	if _, err := fs.ReadFile(fsys, "a/b.go"); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadFile(`a/b.go`) has error %v - should be io.ErrUnexpectedEOF", err)
+	}
+	fsys.Reset().Fail("a/*", faultfs.OpOpen, fs.ErrPermission)
+	if _, err := fs.ReadFile(fsys, "a/c.go"); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("ReadFile(`a/c.go`) has error %v - should be fs.ErrPermission", err)
+	}
+	if data, err := fs.ReadFile(fsys, "f/g.go"); err != nil || data == nil {
+		t.Errorf("ReadFile(`f/g.go`) has error %v - should not", err)
+	}
+}
+
+func TestPartial(t *testing.T) {
+	errTimeout := errors.New("i/o timeout")
+	fsys := faultfs.New(newTestFS()).Partial("a", 1, errTimeout)
+
+	entries, err := fsys.ReadDir("a")
+	if len(entries) != 1 || !errors.Is(err, errTimeout) {
+		t.Errorf("ReadDir(`a`) = %v entries, %v - should have 1 entry and a timeout", len(entries), err)
+	}
+
+	// the same applies to reading an open directory a few entries at a time
+	f, _ := fsys.Open("a")
+	dir := f.(fs.ReadDirFile)
+	entries, err = dir.ReadDir(1)
+	if len(entries) != 1 || err != nil {
+		t.Errorf("ReadDir(1) = %v entries, %v - should have 1 entry", len(entries), err)
+	}
+	entries, err = dir.ReadDir(1)
+	if len(entries) != 0 || !errors.Is(err, errTimeout) {
+		t.Errorf("ReadDir(1) again = %v entries, %v - should have a timeout", len(entries), err)
+	}
+
+	// without an error, entries are silently dropped
+	fsys.Reset().Partial("a", 2, nil)
+	matches, err := doublestar.Glob(fsys, "a/**", doublestar.WithFailOnIOErrors())
+	expected := []string{"a", "a/b.go", "a/c.go"}
+	if err != nil || !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Glob(`a/**`) = %#v, %v - should be %#v", matches, err, expected)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	fsys := faultfs.New(newTestFS()).
+		Delay("a", faultfs.OpReadDir, 20*time.Millisecond).
+		Delay("a", faultfs.OpReadDir, 20*time.Millisecond)
+
+	start := time.Now()
+	doublestar.Glob(fsys, "f/*")
+	doublestar.Glob(fsys, "a/*")
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Glob(`a/*`) took %v - should have been delayed for 40ms", elapsed)
+	}
+}
+
+func TestBadPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Fail(`a[`) should have panicked")
+		}
+	}()
+	faultfs.New(newTestFS()).Fail("a[", faultfs.OpAll, fs.ErrPermission)
+}
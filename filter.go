@@ -0,0 +1,92 @@
+package doublestar
+
+// filter is an internal type to store a compiled pattern and the options
+// passed to Filter or FilterSeq.
+type filter struct {
+	pattern string
+
+	// if the pattern has no meta characters, the name it matches
+	literal   string
+	isLiteral bool
+
+	indexes *[]int
+}
+
+// FilterOption represents a setting that can be passed to Filter or
+// FilterSeq.
+type FilterOption func(*filter)
+
+// WithIndexes is an option that can be passed to Filter or FilterSeq. If
+// passed, the index of each match in the input is appended to `indexes`, in
+// the same order as the matches, so that callers can find any data associated
+// with a match.
+//
+func WithIndexes(indexes *[]int) FilterOption {
+	return func(f *filter) {
+		f.indexes = indexes
+	}
+}
+
+// Validates the pattern and constructs a filter with the given options. The
+// only possible returned error is ErrBadPattern.
+func newFilter(pattern string, opts ...FilterOption) (*filter, error) {
+	if !ValidatePattern(pattern) {
+		return nil, ErrBadPattern
+	}
+
+	f := &filter{pattern: pattern}
+	if indexMeta(pattern) == -1 {
+		f.literal = Unescape(pattern)
+		f.isLiteral = true
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+func (f *filter) match(name string) bool {
+	if f.isLiteral {
+		return name == f.literal
+	}
+
+	// the pattern was validated in newFilter()
+	matched, _ := matchWithSeparator(f.pattern, name, '/', false)
+	return matched
+}
+
+// Records that the name at index `idx` matched, for WithIndexes.
+func (f *filter) matched(idx int) {
+	if f.indexes != nil {
+		*f.indexes = append(*f.indexes, idx)
+	}
+}
+
+// Filter returns the names that match `pattern`, in the same order as
+// `names`, or nil if there are none. The syntax of pattern is the same as in
+// Match(), and the names don't need to be file paths: they might be keys in
+// an object store, the paths of URLs, or entries in an archive, for example.
+//
+// The pattern is validated once, rather than once per name, so Filter is
+// faster than calling Match() in a loop. Pass WithIndexes to also get the
+// index of each match in `names`. See also FilterSeq(), for streams of names,
+// if you are using Go 1.23 or later.
+//
+// The only possible returned error is ErrBadPattern, when pattern is
+// malformed.
+//
+func Filter(pattern string, names []string, opts ...FilterOption) ([]string, error) {
+	f, err := newFilter(pattern, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for i, name := range names {
+		if f.match(name) {
+			matches = append(matches, name)
+			f.matched(i)
+		}
+	}
+	return matches, nil
+}
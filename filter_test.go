@@ -0,0 +1,70 @@
+package doublestar
+
+import (
+	"reflect"
+	"testing"
+)
+
+var filterNames = []string{
+	"src/main.go",
+	"README.md",
+	"src/util/strings.go",
+	"/api/v1/users",
+	"src/main_test.go",
+	"a[b].txt",
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+		indexes  []int
+	}{
+		{"**/*.go", []string{"src/main.go", "src/util/strings.go", "src/main_test.go"}, []int{0, 2, 4}},
+		{"src/*.go", []string{"src/main.go", "src/main_test.go"}, []int{0, 4}},
+		{"/api/*/users", []string{"/api/v1/users"}, []int{3}},
+		{"README.md", []string{"README.md"}, []int{1}},
+		{"a\\[b\\].txt", []string{"a[b].txt"}, []int{5}},
+		{"*.{md,txt}", []string{"README.md", "a[b].txt"}, []int{1, 5}},
+		{"*.rs", nil, nil},
+	}
+
+	for _, tt := range tests {
+		var indexes []int
+		matches, err := Filter(tt.pattern, filterNames, WithIndexes(&indexes))
+		if err != nil || !reflect.DeepEqual(matches, tt.expected) || !reflect.DeepEqual(indexes, tt.indexes) {
+			t.Errorf("Filter(%#q) = %#v, %v with indexes %v - should be %#v with indexes %v", tt.pattern, matches, err, indexes, tt.expected, tt.indexes)
+		}
+	}
+
+	if _, err := Filter("src/[", filterNames); err != ErrBadPattern {
+		t.Errorf("Filter(`src/[`) has error %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestFilterMatchTests(t *testing.T) {
+	for idx, tt := range matchTests {
+		matches, err := Filter(tt.pattern, []string{tt.testPath})
+		if err != tt.expectedErr {
+			t.Errorf("#%v. Filter(%#q) has error %v - should be %v", idx, tt.pattern, err, tt.expectedErr)
+		} else if (len(matches) == 1) != tt.shouldMatch {
+			t.Errorf("#%v. Filter(%#q) with %#q = %#v - should match: %v", idx, tt.pattern, tt.testPath, matches, tt.shouldMatch)
+		}
+	}
+}
+
+func BenchmarkFilter(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Filter("**/*.go", filterNames)
+	}
+}
+
+func BenchmarkFilterWithMatch(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, name := range filterNames {
+			Match("**/*.go", name)
+		}
+	}
+}
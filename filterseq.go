@@ -0,0 +1,36 @@
+//go:build go1.23
+// +build go1.23
+
+package doublestar
+
+import "iter"
+
+// FilterSeq is the streaming equivalent of Filter(): it returns a sequence of
+// the names from `names` that match `pattern`, in the same order. Names are
+// read from `names` lazily, as the returned sequence is iterated, so it can be
+// used to filter large or unbounded streams, such as lines read from a file,
+// without holding them all in memory. If WithIndexes is passed, indexes are
+// appended as matches are yielded, every time the sequence is iterated.
+//
+// The pattern is validated when FilterSeq is called, so the only possible
+// returned error is ErrBadPattern, when pattern is malformed.
+//
+func FilterSeq(pattern string, names iter.Seq[string], opts ...FilterOption) (iter.Seq[string], error) {
+	f, err := newFilter(pattern, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(yield func(string) bool) {
+		i := 0
+		for name := range names {
+			if f.match(name) {
+				f.matched(i)
+				if !yield(name) {
+					return
+				}
+			}
+			i++
+		}
+	}, nil
+}
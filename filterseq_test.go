@@ -0,0 +1,45 @@
+//go:build go1.23
+// +build go1.23
+
+package doublestar
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFilterSeq(t *testing.T) {
+	var indexes []int
+	seq, err := FilterSeq("**/*.go", slices.Values(filterNames), WithIndexes(&indexes))
+	if err != nil {
+		t.Fatalf("FilterSeq(`**/*.go`) has error %v", err)
+	}
+
+	expected := []string{"src/main.go", "src/util/strings.go", "src/main_test.go"}
+	if matches := slices.Collect(seq); !reflect.DeepEqual(matches, expected) || !reflect.DeepEqual(indexes, []int{0, 2, 4}) {
+		t.Errorf("FilterSeq(`**/*.go`) = %#v with indexes %v - should be %#v with indexes [0 2 4]", matches, indexes, expected)
+	}
+
+	// stopping early stops reading names
+	read := 0
+	names := func(yield func(string) bool) {
+		for _, name := range filterNames {
+			read++
+			if !yield(name) {
+				return
+			}
+		}
+	}
+	seq, _ = FilterSeq("src/*", names)
+	for range seq {
+		break
+	}
+	if read != 1 {
+		t.Errorf("FilterSeq(`src/*`) read %d names before the first match - should be 1", read)
+	}
+
+	if _, err := FilterSeq("src/[", slices.Values(filterNames)); err != ErrBadPattern {
+		t.Errorf("FilterSeq(`src/[`) has error %v - should be ErrBadPattern", err)
+	}
+}
@@ -1,10 +1,15 @@
 package doublestar
 
 import (
+	"errors"
 	"io/fs"
 	"path"
 )
 
+// errLimitReached is used internally to stop traversal once the limit set by
+// WithLimit has been reached. It is never returned to the caller.
+var errLimitReached = errors.New("doublestar: limit reached")
+
 // Glob returns the names of all files matching pattern or nil if there is no
 // matching file. The syntax of pattern is the same as in Match(). The pattern
 // may describe hierarchical names such as usr/*/bin/ed.
@@ -14,7 +19,10 @@ import (
 // the pattern is malformed.
 //
 // To enable aborting on I/O errors, the WithFailOnIOErrors option can be
-// passed.
+// passed. For finer control, an ErrorHandler can be passed with the
+// WithErrorHandler option instead. To stop after a certain number of matches,
+// the WithLimit option can be passed. To collect every I/O error while still
+// returning all of the matches, the WithCollectIOErrors option can be passed.
 //
 // Note: this is meant as a drop-in replacement for io/fs.Glob(). Like
 // io/fs.Glob(), this function assumes that your pattern uses `/` as the path
@@ -34,21 +42,54 @@ func Glob(fsys fs.FS, pattern string, opts ...GlobOption) ([]string, error) {
 		return nil, ErrBadPattern
 	}
 
-	g := newGlob(opts...)
+	return newGlob(opts...).glob(fsys, pattern)
+}
+
+// Runs Glob() with the given options - assumes the pattern has already been
+// validated.
+func (g *glob) glob(fsys fs.FS, pattern string) ([]string, error) {
+	defer g.startStats()()
 
-	if hasMidDoubleStar(pattern) {
+	if g.limit > 0 || hasMidDoubleStar(pattern) {
 		// If the pattern has a `**` anywhere but the very end, GlobWalk is more
 		// performant because it can get away with less allocations. If the pattern
 		// ends in a `**`, both methods are pretty much the same, but Glob has a
 		// _very_ slight advantage because of lower function call overhead.
+		// GlobWalk is also able to stop as soon as the limit is reached, whereas
+		// doGlob would need to finish building every intermediate result first.
 		var matches []string
-		err := g.doGlobWalk(fsys, pattern, true, func(p string, d fs.DirEntry) error {
+		err := g.doGlobWalk(fsys, pattern, true, g.limitWalkFunc(func(p string, d fs.DirEntry) error {
 			matches = append(matches, p)
 			return nil
-		})
-		return matches, err
+		}))
+		if err == errLimitReached {
+			err = nil
+		}
+		g.countMatches(len(matches))
+		return matches, g.collectedIOErrors(err)
 	}
-	return g.doGlob(fsys, pattern, nil, true)
+
+	matches, err := g.doGlob(fsys, pattern, nil, true)
+	g.countMatches(len(matches))
+	return matches, g.collectedIOErrors(err)
+}
+
+// GlobExists returns true if at least one file matches pattern. The syntax of
+// pattern is the same as in Match(), and the behavior is the same as Glob(),
+// except that traversal stops as soon as the first match is found. In other
+// words, this is equivalent to passing WithLimit(1) to Glob() and checking if
+// there were any results.
+//
+// GlobExists ignores file system errors such as I/O errors reading directories
+// by default. The only possible returned error is ErrBadPattern, reporting that
+// the pattern is malformed. To enable aborting on I/O errors, the
+// WithFailOnIOErrors option can be passed.
+//
+func GlobExists(fsys fs.FS, pattern string, opts ...GlobOption) (bool, error) {
+	// use a full slice expression so append doesn't clobber the caller's slice
+	opts = append(opts[:len(opts):len(opts)], WithLimit(1))
+	matches, err := Glob(fsys, pattern, opts...)
+	return len(matches) > 0, err
 }
 
 // Does the actual globbin'
@@ -59,7 +100,7 @@ func (g *glob) doGlob(fsys fs.FS, pattern string, m []string, firstSegment bool)
 		// pattern doesn't contain any meta characters - does a file matching the
 		// pattern exist?
 		// The pattern may contain escaped wildcard characters for an exact path match.
-		path := unescapeMeta(pattern)
+		path := Unescape(pattern)
 		pathExists, pathErr := g.exists(fsys, path)
 		if pathErr != nil {
 			return nil, pathErr
@@ -199,9 +240,9 @@ func (g *glob) globDir(fsys fs.FS, dir, pattern string, matches []string, canMat
 		return g.globDoubleStar(fsys, dir, m, canMatchFiles)
 	}
 
-	dirs, err := fs.ReadDir(fsys, dir)
+	dirs, err := g.readDir(fsys, dir)
 	if err != nil {
-		if g.failOnIOErrors {
+		if err = g.forwardIOError("readdir", dir, err); err != nil {
 			return nil, err
 		}
 		return
@@ -209,15 +250,25 @@ func (g *glob) globDir(fsys fs.FS, dir, pattern string, matches []string, canMat
 
 	var matched bool
 	for _, info := range dirs {
+		if g.stats != nil {
+			g.stats.EntriesExamined++
+		}
 		name := info.Name()
 		matched = canMatchFiles
 		if !matched {
 			matched, e = g.isDir(fsys, dir, name, info)
+			if e == SkipDir {
+				e = nil
+				continue
+			}
 			if e != nil {
 				return
 			}
 		}
 		if matched {
+			if g.stats != nil {
+				g.stats.MatchAttempts++
+			}
 			matched, e = matchWithSeparator(pattern, name, '/', false)
 			if e != nil {
 				return
@@ -232,9 +283,9 @@ func (g *glob) globDir(fsys fs.FS, dir, pattern string, matches []string, canMat
 }
 
 func (g *glob) globDoubleStar(fsys fs.FS, dir string, matches []string, canMatchFiles bool) ([]string, error) {
-	dirs, err := fs.ReadDir(fsys, dir)
+	dirs, err := g.readDir(fsys, dir)
 	if err != nil {
-		if g.failOnIOErrors {
+		if err = g.forwardIOError("readdir", dir, err); err != nil {
 			return nil, err
 		}
 		return matches, nil
@@ -243,8 +294,14 @@ func (g *glob) globDoubleStar(fsys fs.FS, dir string, matches []string, canMatch
 	// `**` can match *this* dir, so add it
 	matches = append(matches, dir)
 	for _, info := range dirs {
+		if g.stats != nil {
+			g.stats.EntriesExamined++
+		}
 		name := info.Name()
 		isDir, err := g.isDir(fsys, dir, name, info)
+		if err == SkipDir {
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -335,17 +392,56 @@ func indexMatchedOpeningAlt(s string) int {
 	return -1
 }
 
+// Calls fs.ReadDir(), counting the call if WithStats was passed, or returns
+// the cached result if WithDirCache was passed.
+func (g *glob) readDir(fsys fs.FS, name string) ([]fs.DirEntry, error) {
+	if g.dirCache != nil {
+		return g.dirCache.readDir(fsys, name, g.dirCacheKey(name), g.stats)
+	}
+	if g.stats != nil {
+		g.stats.ReadDirCalls++
+	}
+	return fs.ReadDir(fsys, name)
+}
+
+// Calls fs.Stat(), counting the call if WithStats was passed, or returns the
+// cached result if WithDirCache was passed.
+func (g *glob) stat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if g.dirCache != nil {
+		return g.dirCache.stat(fsys, name, g.dirCacheKey(name), g.stats)
+	}
+	if g.stats != nil {
+		g.stats.StatCalls++
+	}
+	return fs.Stat(fsys, name)
+}
+
+// Returns the name that the result of an I/O call on `name` is cached under.
+// If the fs.FS was created with os.DirFS(osBase), it's joined to osBase so
+// that calls with different base paths can share a DirCache. If the fs.FS is
+// an archive, it's prefixed with the archive's path, so that it doesn't clash
+// with paths outside of the archive.
+func (g *glob) dirCacheKey(name string) string {
+	if g.osBase != "" {
+		return path.Join(g.osBase, name)
+	}
+	return g.archivePrefix + name
+}
+
 // Returns true if the path exists
 func (g *glob) exists(fsys fs.FS, name string) (bool, error) {
-	_, err := fs.Stat(fsys, name)
-	return err == nil, g.forwardErrIfFailOnIOErrors(err)
+	_, err := g.stat(fsys, name)
+	if err != nil {
+		return false, g.forwardIOError("stat", name, err)
+	}
+	return true, nil
 }
 
 // Returns true if the path is a directory, or a symlink to a directory
 func (g *glob) isPathDir(fsys fs.FS, name string) (bool, error) {
-	info, err := fs.Stat(fsys, name)
+	info, err := g.stat(fsys, name)
 	if err != nil {
-		return false, g.forwardErrIfFailOnIOErrors(err)
+		return false, g.forwardIOError("stat", name, err)
 	}
 
 	return info.IsDir(), nil
@@ -353,16 +449,21 @@ func (g *glob) isPathDir(fsys fs.FS, name string) (bool, error) {
 
 // Returns whether or not the given DirEntry is a directory. If the DirEntry
 // represents a symbolic link, the link is followed by running fs.Stat() on
-// `path.Join(dir, name)` (if dir is "", name will be used without joining)
+// `path.Join(dir, name)` (if dir is "", name will be used without joining).
+// If fs.Stat() fails and the error handler returns SkipDir, SkipDir is
+// returned so the caller can skip the entry entirely.
 func (g *glob) isDir(fsys fs.FS, dir, name string, info fs.DirEntry) (bool, error) {
 	if (info.Type() & fs.ModeSymlink) > 0 {
+		if g.stats != nil {
+			g.stats.SymlinksResolved++
+		}
 		p := name
 		if dir != "" {
 			p = path.Join(dir, name)
 		}
-		finfo, err := fs.Stat(fsys, p)
+		finfo, err := g.stat(fsys, p)
 		if err != nil {
-			return false, g.forwardErrIfFailOnIOErrors(err)
+			return false, g.handleIOError("stat", p, err)
 		}
 		return finfo.IsDir(), nil
 	}
@@ -1,12 +1,46 @@
 package doublestar
 
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
 // glob is an internal type to store options during globbing.
 type glob struct {
-	failOnIOErrors bool
+	failOnIOErrors  bool
+	collectIOErrors bool
+	errorHandler    ErrorHandler
+	limit           int
+	stats           *GlobStats
+	dirCache        *DirCache
+
+	// I/O errors collected during globbing when collectIOErrors is enabled
+	ioErrors []error
+
+	// If set, the fs.FS was created with os.DirFS(osBase), so paths reported to
+	// the error handler and in GlobIOError are joined to osBase and converted to
+	// the OS path separator. See FilepathGlob and OSGlob.
+	osBase string
+
+	// If set, the fs.FS is an archive, and paths reported to the error handler
+	// and in GlobIOError are prefixed with the path of the archive. See
+	// ArchiveGlob.
+	archivePrefix string
 }
 
-// GlobOption represents a setting that can be passed to Glob, GlobWalk, and
-// FilepathGlob.
+// ErrorHandler is a callback function that can be passed to WithErrorHandler.
+// It is called with the path (relative to the fs.FS) and error whenever an I/O
+// function fails during globbing. Returning nil ignores the error and
+// continues, returning SkipDir skips the offending path and anything below it,
+// and returning any other error aborts globbing with that error.
+type ErrorHandler func(path string, err error) error
+
+// GlobOption represents a setting that can be passed to Glob, GlobWalk,
+// FilepathGlob, and FilepathGlobWalk.
 type GlobOption func(*glob)
 
 // Construct a new glob object with the given options
@@ -28,19 +62,190 @@ func WithFailOnIOErrors() GlobOption {
 	}
 }
 
-// forwardErrIfFailOnIOErrors is used to wrap the return values of I/O
-// functions. When failOnIOErrors is enabled, it will return err; otherwise, it
-// always returns nil.
-func (g *glob) forwardErrIfFailOnIOErrors(err error) error {
-	if g.failOnIOErrors {
+// WithCollectIOErrors is an option that can be passed to Glob, GlobWalk, or
+// FilepathGlob. If passed, every IO error encountered is recorded as a
+// *GlobIOError and globbing continues. Once globbing is finished, all of the
+// matches are returned along with an error that joins every recorded
+// *GlobIOError. The joined error is compatible with errors.Join(): it has an
+// `Unwrap() []error` method, so errors.As() can be used to extract a
+// *GlobIOError.
+//
+// If WithFailOnIOErrors or WithErrorHandler are also passed, IO errors are
+// still recorded, but those options decide whether globbing should abort.
+//
+func WithCollectIOErrors() GlobOption {
+	return func(g *glob) {
+		g.collectIOErrors = true
+	}
+}
+
+// WithErrorHandler is an option that can be passed to Glob, GlobWalk, or
+// FilepathGlob. If passed, `fn` is called for every IO error encountered,
+// which allows errors to be logged (or otherwise inspected) before deciding
+// whether to continue. See ErrorHandler for how its return value is handled.
+//
+// WithErrorHandler takes precedence over WithFailOnIOErrors: if both are
+// passed, only the error handler decides whether to abort.
+//
+func WithErrorHandler(fn ErrorHandler) GlobOption {
+	return func(g *glob) {
+		g.errorHandler = fn
+	}
+}
+
+// WithLimit is an option that can be passed to Glob, GlobWalk, or
+// FilepathGlob. If passed, traversal stops as soon as `n` matches have been
+// produced. A limit of zero or less means there is no limit.
+//
+func WithLimit(n int) GlobOption {
+	return func(g *glob) {
+		g.limit = n
+	}
+}
+
+// GlobStats records how much work globbing did, to help tune patterns. See
+// WithStats.
+type GlobStats struct {
+	// ReadDirCalls and StatCalls are the number of calls to fs.ReadDir() and
+	// fs.Stat(), including any fs.Stat() calls to resolve symlinks. Results
+	// found in a DirCache (see WithDirCache) aren't counted.
+	ReadDirCalls int
+	StatCalls    int
+
+	// EntriesExamined is the number of directory entries that were looked at,
+	// and MatchAttempts is the number of them that were compared against a
+	// pattern.
+	EntriesExamined int
+	MatchAttempts   int
+
+	// Matches is the number of matches produced.
+	Matches int
+
+	// SymlinksResolved is the number of directory entries that were symlinks
+	// and had to be followed to find out if they point to a directory.
+	SymlinksResolved int
+
+	// ErrorsIgnored is the number of I/O errors that did not abort globbing.
+	ErrorsIgnored int
+
+	// Duration is the wall time spent globbing.
+	Duration time.Duration
+}
+
+// WithStats is an option that can be passed to Glob, GlobWalk, or
+// FilepathGlob. If passed, `stats` is filled in with how many I/O calls were
+// made, how many directory entries were examined, and so on, as globbing
+// runs. The counts are added to whatever is already in `stats`, so the same
+// GlobStats can be passed to several calls to get a total. `stats` must not be
+// used by more than one call at the same time.
+//
+func WithStats(stats *GlobStats) GlobOption {
+	return func(g *glob) {
+		g.stats = stats
+	}
+}
+
+// Starts timing a glob for WithStats. The returned function stops timing and
+// must be called when globbing is finished.
+func (g *glob) startStats() func() {
+	if g.stats == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		g.stats.Duration += time.Since(start)
+	}
+}
+
+// If a GlobStats was passed with WithStats, wraps `fn` so that it will count
+// matches. Otherwise, `fn` is returned unaltered.
+func (g *glob) statsWalkFunc(fn GlobWalkFunc) GlobWalkFunc {
+	if g.stats == nil {
+		return fn
+	}
+
+	return func(p string, d fs.DirEntry) error {
+		g.stats.Matches++
+		return fn(p, d)
+	}
+}
+
+// If a GlobStats was passed with WithStats, adds `n` to the number of matches.
+func (g *glob) countMatches(n int) {
+	if g.stats != nil {
+		g.stats.Matches += n
+	}
+}
+
+// handleIOError is called whenever the I/O function `op` fails on `name`. If
+// osBase is set, `name` is converted to an OS path first, and if
+// archivePrefix is set, it is prefixed to `name`. If collectIOErrors
+// is enabled, the error is recorded. Then, if an error handler
+// was set, its return value is returned as-is, which may be SkipDir.
+// Otherwise, when failOnIOErrors is enabled, it will return err; otherwise, it
+// returns nil.
+func (g *glob) handleIOError(op, name string, err error) error {
+	if g.osBase != "" {
+		name = filepath.FromSlash(path.Join(g.osBase, name))
+	}
+	name = g.archivePrefix + name
+	if g.collectIOErrors {
+		g.ioErrors = append(g.ioErrors, &GlobIOError{Path: name, Op: op, Err: err})
+	}
+	if g.errorHandler != nil {
+		err = g.errorHandler(name, err)
+	} else if !g.failOnIOErrors {
+		err = nil
+	}
+	if g.stats != nil && (err == nil || err == SkipDir) {
+		g.stats.ErrorsIgnored++
+	}
+	return err
+}
+
+// forwardIOError is used to wrap the return values of I/O functions where
+// there is nothing left to skip. It's the same as handleIOError, except that
+// SkipDir is treated like nil.
+func (g *glob) forwardIOError(op, name string, err error) error {
+	if err = g.handleIOError(op, name, err); err == SkipDir {
+		return nil
+	}
+	return err
+}
+
+// collectedIOErrors returns err if it is not nil. Otherwise, it returns any
+// I/O errors that were collected when collectIOErrors is enabled, joined
+// together, or nil if there weren't any.
+func (g *glob) collectedIOErrors(err error) error {
+	if err != nil || len(g.ioErrors) == 0 {
 		return err
 	}
-	return nil
+	return joinedIOErrors(g.ioErrors)
 }
 
 func (g *glob) GoString() string {
+	var opts []string
 	if g.failOnIOErrors {
-		return "opts: WithFailOnIOErrors"
+		opts = append(opts, "WithFailOnIOErrors")
+	}
+	if g.collectIOErrors {
+		opts = append(opts, "WithCollectIOErrors")
+	}
+	if g.errorHandler != nil {
+		opts = append(opts, "WithErrorHandler")
+	}
+	if g.limit > 0 {
+		opts = append(opts, fmt.Sprintf("WithLimit(%d)", g.limit))
+	}
+	if g.stats != nil {
+		opts = append(opts, "WithStats")
+	}
+	if g.dirCache != nil {
+		opts = append(opts, "WithDirCache")
+	}
+	if len(opts) == 0 {
+		return "opts: nil"
 	}
-	return "opts: nil"
+	return "opts: " + strings.Join(opts, ", ")
 }
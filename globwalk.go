@@ -37,7 +37,10 @@ type GlobWalkFunc func(path string, d fs.DirEntry) error
 // malformed.
 //
 // To enable aborting on I/O errors, the WithFailOnIOErrors option can be
-// passed.
+// passed. For finer control, an ErrorHandler can be passed with the
+// WithErrorHandler option instead. To stop after a certain number of matches,
+// the WithLimit option can be passed. To collect every I/O error while still
+// walking every match, the WithCollectIOErrors option can be passed.
 //
 // Additionally, if the callback function `fn` returns an error, GlobWalk will
 // exit immediately and return that error.
@@ -55,8 +58,38 @@ func GlobWalk(fsys fs.FS, pattern string, fn GlobWalkFunc, opts ...GlobOption) e
 		return ErrBadPattern
 	}
 
-	g := newGlob(opts...)
-	return g.doGlobWalk(fsys, pattern, true, fn)
+	return newGlob(opts...).globWalk(fsys, pattern, fn)
+}
+
+// Runs GlobWalk() with the given options - assumes the pattern has already
+// been validated.
+func (g *glob) globWalk(fsys fs.FS, pattern string, fn GlobWalkFunc) error {
+	defer g.startStats()()
+
+	err := g.doGlobWalk(fsys, pattern, true, g.limitWalkFunc(g.statsWalkFunc(fn)))
+	if err == errLimitReached {
+		err = nil
+	}
+	return g.collectedIOErrors(err)
+}
+
+// If a limit was set with WithLimit, wraps `fn` so that it will return
+// errLimitReached once enough matches have been produced. Otherwise, `fn` is
+// returned unaltered.
+func (g *glob) limitWalkFunc(fn GlobWalkFunc) GlobWalkFunc {
+	if g.limit <= 0 {
+		return fn
+	}
+
+	count := 0
+	return func(p string, d fs.DirEntry) error {
+		err := fn(p, d)
+		// a match that returned SkipDir still counts toward the limit
+		if count++; (err == nil || err == SkipDir) && count >= g.limit {
+			err = errLimitReached
+		}
+		return err
+	}
 }
 
 // Actually execute GlobWalk
@@ -66,8 +99,8 @@ func (g *glob) doGlobWalk(fsys fs.FS, pattern string, firstSegment bool, fn Glob
 		// pattern doesn't contain any meta characters - does a file matching the
 		// pattern exist?
 		// The pattern may contain escaped wildcard characters for an exact path match.
-		path := unescapeMeta(pattern)
-		info, err := fs.Stat(fsys, path)
+		path := Unescape(pattern)
+		info, err := g.stat(fsys, path)
 		if err == nil {
 			err = fn(path, dirEntryFromFileInfo(info))
 			if err == SkipDir {
@@ -75,7 +108,7 @@ func (g *glob) doGlobWalk(fsys fs.FS, pattern string, firstSegment bool, fn Glob
 			}
 			return err
 		} else {
-			return g.forwardErrIfFailOnIOErrors(err)
+			return g.forwardIOError("stat", path, err)
 		}
 	}
 
@@ -161,6 +194,11 @@ func (g *glob) globAltsWalk(fsys fs.FS, pattern string, openingIdx, closingIdx i
 		if err = fn(m.Path, m.Entry); err != nil {
 			if err == SkipDir {
 				isDir, err := g.isDir(fsys, "", m.Path, m.Entry)
+				if err == SkipDir {
+					// the error handler asked to skip m.Path, which is what we're
+					// about to do anyway, so treat it like a directory
+					isDir, err = true, nil
+				}
 				if err != nil {
 					return err
 				}
@@ -170,7 +208,13 @@ func (g *glob) globAltsWalk(fsys fs.FS, pattern string, openingIdx, closingIdx i
 				} else {
 					// Dir() calls Clean() which calls FromSlash(), so we need to convert
 					// back to slashes
-					skip = filepath.ToSlash(filepath.Dir(m.Path)) + "/"
+					dir := filepath.ToSlash(filepath.Dir(m.Path))
+					if dir == "." {
+						// m.Path is in the root of fsys, so all of the remaining matches
+						// are in the same directory and need to be skipped
+						return nil
+					}
+					skip = dir + "/"
 				}
 				err = nil
 				continue
@@ -233,9 +277,9 @@ func (g *glob) globDirWalk(fsys fs.FS, dir, pattern string, canMatchFiles bool,
 		// pattern can be an empty string if the original pattern ended in a slash,
 		// in which case, we should just return dir, but only if it actually exists
 		// and it's a directory (or a symlink to a directory)
-		info, err := fs.Stat(fsys, dir)
+		info, err := g.stat(fsys, dir)
 		if err != nil {
-			return g.forwardErrIfFailOnIOErrors(err)
+			return g.forwardIOError("stat", dir, err)
 		}
 		if !info.IsDir() {
 			return nil
@@ -250,9 +294,9 @@ func (g *glob) globDirWalk(fsys fs.FS, dir, pattern string, canMatchFiles bool,
 
 	if pattern == "**" {
 		// `**` can match *this* dir
-		info, err := fs.Stat(fsys, dir)
+		info, err := g.stat(fsys, dir)
 		if err != nil {
-			return g.forwardErrIfFailOnIOErrors(err)
+			return g.forwardIOError("stat", dir, err)
 		}
 		if !info.IsDir() {
 			return nil
@@ -266,22 +310,32 @@ func (g *glob) globDirWalk(fsys fs.FS, dir, pattern string, canMatchFiles bool,
 		return g.globDoubleStarWalk(fsys, dir, canMatchFiles, fn)
 	}
 
-	dirs, err := fs.ReadDir(fsys, dir)
+	dirs, err := g.readDir(fsys, dir)
 	if err != nil {
-		return g.forwardErrIfFailOnIOErrors(err)
+		return g.forwardIOError("readdir", dir, err)
 	}
 
 	var matched bool
 	for _, info := range dirs {
+		if g.stats != nil {
+			g.stats.EntriesExamined++
+		}
 		name := info.Name()
 		matched = canMatchFiles
 		if !matched {
 			matched, e = g.isDir(fsys, dir, name, info)
+			if e == SkipDir {
+				e = nil
+				continue
+			}
 			if e != nil {
 				return e
 			}
 		}
 		if matched {
+			if g.stats != nil {
+				g.stats.MatchAttempts++
+			}
 			matched, e = matchWithSeparator(pattern, name, '/', false)
 			if e != nil {
 				return
@@ -302,15 +356,21 @@ func (g *glob) globDirWalk(fsys fs.FS, dir, pattern string, canMatchFiles bool,
 
 // recursively walk files/directories in a directory
 func (g *glob) globDoubleStarWalk(fsys fs.FS, dir string, canMatchFiles bool, fn GlobWalkFunc) (e error) {
-	dirs, err := fs.ReadDir(fsys, dir)
+	dirs, err := g.readDir(fsys, dir)
 	if err != nil {
-		return g.forwardErrIfFailOnIOErrors(err)
+		return g.forwardIOError("readdir", dir, err)
 	}
 
 	// `**` can match *this* dir, so add it
 	for _, info := range dirs {
+		if g.stats != nil {
+			g.stats.EntriesExamined++
+		}
 		name := info.Name()
 		isDir, err := g.isDir(fsys, dir, name, info)
+		if err == SkipDir {
+			continue
+		}
 		if err != nil {
 			return err
 		}
@@ -3,6 +3,7 @@ package doublestar
 import (
 	"io/fs"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -63,3 +64,50 @@ func testSkipDirInGlobWalkWith(t *testing.T, idx int, tt SkipTest, fsys fs.FS) {
 		t.Errorf("#%v. GlobWalk(%#q) should not have matched %#q, but did", idx, tt.pattern, tt.shouldNotContain)
 	}
 }
+
+func TestSkipDirInFilepathGlobWalk(t *testing.T) {
+	// The patterns are relative to the "test" sub-directory.
+	defer func() {
+		os.Chdir("..")
+	}()
+	os.Chdir("test")
+
+	for idx, tt := range skipTests {
+		testSkipDirInFilepathGlobWalkWith(t, idx, tt)
+	}
+}
+
+func testSkipDirInFilepathGlobWalkWith(t *testing.T, idx int, tt SkipTest) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. FilepathGlobWalk(%#q) panicked: %#v", idx, tt.pattern, r)
+		}
+	}()
+
+	skipOn := filepath.FromSlash(tt.skipOn)
+	shouldNotContain := filepath.FromSlash(tt.shouldNotContain)
+
+	var matches []string
+	hadBadMatch := false
+	FilepathGlobWalk(filepath.FromSlash(tt.pattern), func(p string, d fs.DirEntry) error {
+		if p == skipOn {
+			return SkipDir
+		}
+		if p == shouldNotContain {
+			hadBadMatch = true
+		}
+		matches = append(matches, p)
+		return nil
+	})
+
+	expected := tt.numResults
+	if onWindows {
+		expected = tt.winNumResults
+	}
+	if len(matches) != expected {
+		t.Errorf("#%v. FilepathGlobWalk(%#q) = %#v - should have %#v results, got %#v", idx, tt.pattern, matches, expected, len(matches))
+	}
+	if hadBadMatch {
+		t.Errorf("#%v. FilepathGlobWalk(%#q) should not have matched %#q, but did", idx, tt.pattern, tt.shouldNotContain)
+	}
+}
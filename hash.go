@@ -0,0 +1,181 @@
+package doublestar
+
+import (
+	"hash"
+	"io"
+	"io/fs"
+	"sort"
+	"sync"
+)
+
+// hasher is an internal type to store the options passed to HashGlob.
+type hasher struct {
+	globOpts []GlobOption
+	workers  int
+}
+
+// HashOption represents a setting that can be passed to HashGlob.
+type HashOption func(*hasher)
+
+// WithGlobOptions is an option that can be passed to HashGlob. If passed,
+// `opts` are passed to GlobWalk() for each pattern, to control how I/O errors
+// are handled, for example.
+//
+func WithGlobOptions(opts ...GlobOption) HashOption {
+	return func(h *hasher) {
+		h.globOpts = append(h.globOpts, opts...)
+	}
+}
+
+// WithHashWorkers is an option that can be passed to HashGlob. If passed,
+// up to `n` files are read and hashed at the same time, which can be much
+// faster on storage with high latency, such as network filesystems. The result
+// is the same no matter how many workers are used. By default, or if `n` is
+// less than 2, files are hashed one at a time.
+//
+func WithHashWorkers(n int) HashOption {
+	return func(h *hasher) {
+		h.workers = n
+	}
+}
+
+// HashGlob returns a hash of every file that matches any of `patterns`, made
+// with the hash functions returned by `h` (such as sha256.New), which is
+// useful as a cache key, as in "the hash of every `**/go.sum` and
+// `**/*.proto`". Directories (including symlinks to directories) are ignored,
+// and files that match more than one pattern are only hashed once. Pass
+// WithHashWorkers to hash files in parallel, and WithGlobOptions to pass
+// options to GlobWalk().
+//
+// The result only depends on the paths and contents of the matching files,
+// not on the order of `patterns` or the order of directory listings: files
+// are sorted by path, each file's contents are hashed, and then each path,
+// followed by a NUL byte and the hash of its contents, is hashed to make the
+// result. Modification times and modes aren't hashed.
+//
+// HashGlob returns ErrBadPattern if any of the patterns is malformed, without
+// reading anything. Errors reading a matched file are always returned, since
+// ignoring them would produce a hash that doesn't reflect the file. I/O
+// errors while globbing are handled according to the options passed with
+// WithGlobOptions, as in GlobWalk(); WithCollectIOErrors is treated like
+// WithFailOnIOErrors, since no hash is returned along with the errors.
+//
+func HashGlob(fsys fs.FS, patterns []string, h func() hash.Hash, opts ...HashOption) ([]byte, error) {
+	for _, pattern := range patterns {
+		if !ValidatePattern(pattern) {
+			return nil, ErrBadPattern
+		}
+	}
+
+	hs := &hasher{}
+	for _, opt := range opts {
+		opt(hs)
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		err := GlobWalk(fsys, pattern, func(p string, d fs.DirEntry) error {
+			if seen[p] || d.IsDir() {
+				return nil
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				if info, err := fs.Stat(fsys, p); err == nil && info.IsDir() {
+					return nil
+				}
+			}
+			seen[p] = true
+			paths = append(paths, p)
+			return nil
+		}, hs.globOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	digests, err := hashFiles(fsys, paths, h, hs.workers)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := h()
+	for i, p := range paths {
+		io.WriteString(sum, p)
+		sum.Write([]byte{0})
+		sum.Write(digests[i])
+	}
+	return sum.Sum(nil), nil
+}
+
+// Hashes the contents of each of `paths`, using up to `workers` goroutines.
+// Returns the first error, if any.
+func hashFiles(fsys fs.FS, paths []string, h func() hash.Hash, workers int) ([][]byte, error) {
+	digests := make([][]byte, len(paths))
+	if workers < 2 {
+		for i, p := range paths {
+			digest, err := hashFile(fsys, p, h)
+			if err != nil {
+				return nil, err
+			}
+			digests[i] = digest
+		}
+		return digests, nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				digest, err := hashFile(fsys, paths[i], h)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					continue
+				}
+				digests[i] = digest
+			}
+		}()
+	}
+
+	for i := range paths {
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return digests, nil
+}
+
+func hashFile(fsys fs.FS, name string, h func() hash.Hash) ([]byte, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sum := h()
+	if _, err := io.Copy(sum, f); err != nil {
+		return nil, err
+	}
+	return sum.Sum(nil), nil
+}
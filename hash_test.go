@@ -0,0 +1,88 @@
+package doublestar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+func TestHashGlob(t *testing.T) {
+	fsys := memfs.New().
+		File("go.sum", []byte("sum")).
+		File("api/a.proto", []byte("a")).
+		File("api/v1/b.proto", []byte("b")).
+		File("api/README", []byte("readme")).
+		Dir("api/dir.proto").
+		Symlink("link.proto", "api/v1")
+
+	// the result is the hash of each path, a NUL, and the hash of its contents;
+	// link.proto is a symlink to a directory, so it's skipped, but files in it
+	// aren't
+	expected := sha256.New()
+	for _, file := range []struct{ path, data string }{
+		{"api/a.proto", "a"},
+		{"api/v1/b.proto", "b"},
+		{"go.sum", "sum"},
+		{"link.proto/b.proto", "b"},
+	} {
+		digest := sha256.Sum256([]byte(file.data))
+		expected.Write([]byte(file.path + "\x00"))
+		expected.Write(digest[:])
+	}
+
+	patterns := [][]string{
+		{"**/go.sum", "**/*.proto"},
+		{"**/*.proto", "**/go.sum", "api/*.proto"},
+	}
+	for _, p := range patterns {
+		for _, workers := range []int{0, 4} {
+			sum, err := HashGlob(fsys, p, sha256.New, WithHashWorkers(workers))
+			if err != nil || !bytes.Equal(sum, expected.Sum(nil)) {
+				t.Errorf("HashGlob(%#v, WithHashWorkers(%d)) = %x, %v - should be %x", p, workers, sum, err, expected.Sum(nil))
+			}
+		}
+	}
+
+	before, _ := HashGlob(fsys, patterns[0], sha256.New)
+	fsys.File("api/a.proto", []byte("changed"))
+	if after, _ := HashGlob(fsys, patterns[0], sha256.New); bytes.Equal(before, after) {
+		t.Errorf("HashGlob(%#v) didn't change when a file's contents changed", patterns[0])
+	}
+
+	if _, err := HashGlob(fsys, []string{"**/go.sum", "["}, sha256.New); err != ErrBadPattern {
+		t.Errorf("HashGlob(`[`) has error %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestHashGlobReadError(t *testing.T) {
+	fsys := memfs.New()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"} {
+		fsys.File(name, []byte(name))
+	}
+	fsys.Error("c.txt", fs.ErrPermission)
+
+	for _, workers := range []int{0, 2} {
+		if sum, err := HashGlob(fsys, []string{"*.txt"}, sha256.New, WithHashWorkers(workers)); !errors.Is(err, fs.ErrPermission) || sum != nil {
+			t.Errorf("HashGlob(`*.txt`, WithHashWorkers(%d)) = %x, %v - should be fs.ErrPermission", workers, sum, err)
+		}
+	}
+}
+
+func TestHashGlobGlobOptions(t *testing.T) {
+	fsys := memfs.New().
+		File("a.txt", []byte("a")).
+		File("sub/b.txt", []byte("b")).
+		Error("sub", fs.ErrPermission)
+
+	// by default, GlobWalk ignores the unreadable directory
+	if _, err := HashGlob(fsys, []string{"**/*.txt"}, sha256.New); err != nil {
+		t.Errorf("HashGlob(`**/*.txt`) has error %v - should be nil", err)
+	}
+	if _, err := HashGlob(fsys, []string{"**/*.txt"}, sha256.New, WithGlobOptions(WithFailOnIOErrors())); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("HashGlob(`**/*.txt`, WithGlobOptions(WithFailOnIOErrors())) has error %v - should be fs.ErrPermission", err)
+	}
+}
@@ -0,0 +1,94 @@
+package doublestar_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/bmatcuk/doublestar/v4/faultfs"
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+// Each of these injects a fault into one of the I/O calls that Glob and
+// GlobWalk make, so that every error path is covered.
+var ioErrorTests = []struct {
+	pattern     string
+	faultPath   string
+	faultOp     faultfs.Op
+	numResults  int // without WithFailOnIOErrors
+	expectedOp  string
+	description string
+}{
+	{"a/*.go", "a", faultfs.OpReadDir, 0, "readdir", "reading a directory"},
+	{"a/**", "a/d", faultfs.OpReadDir, 3, "readdir", "reading a directory for `**`"},
+	{"a/**/e.go", "a/d", faultfs.OpReadDir, 0, "readdir", "reading a directory for a mid-pattern `**`"},
+	{"a/b.go", "a/b.go", faultfs.OpStat, 0, "stat", "checking if a file exists"},
+	{"a/*/", "a/d", faultfs.OpStat, 0, "stat", "checking if a path is a directory"},
+	{"*/*.go", "l", faultfs.OpStat, 2, "stat", "following a symlink"},
+	{"{a,l}/e.go", "l", faultfs.OpStat, 0, "stat", "checking if a path with alternatives exists"},
+}
+
+func TestIOErrors(t *testing.T) {
+	base := memfs.New().
+		File("a/b.go", nil).
+		File("a/c.go", nil).
+		File("a/d/e.go", nil).
+		Symlink("l", "a/d")
+
+	for _, tt := range ioErrorTests {
+		fsys := faultfs.New(base).Fail(tt.faultPath, tt.faultOp, fs.ErrPermission)
+
+		matches, err := doublestar.Glob(fsys, tt.pattern)
+		if err != nil || len(matches) != tt.numResults {
+			t.Errorf("%v: Glob(%#q) = %#v, %v - should have %v results", tt.description, tt.pattern, matches, err, tt.numResults)
+		}
+
+		_, err = doublestar.Glob(fsys, tt.pattern, doublestar.WithFailOnIOErrors())
+		checkIOError(t, tt.description, "Glob", tt.pattern, tt.faultPath, tt.expectedOp, err)
+
+		err = doublestar.GlobWalk(fsys, tt.pattern, func(p string, d fs.DirEntry) error {
+			return nil
+		}, doublestar.WithFailOnIOErrors())
+		checkIOError(t, tt.description, "GlobWalk", tt.pattern, tt.faultPath, tt.expectedOp, err)
+
+		_, err = doublestar.Glob(fsys, tt.pattern, doublestar.WithCollectIOErrors())
+		var ioErr *doublestar.GlobIOError
+		if !errors.As(err, &ioErr) || ioErr.Path != tt.faultPath || ioErr.Op != tt.expectedOp {
+			t.Errorf("%v: Glob(%#q, WithCollectIOErrors) has error %v - should have a %v error for %v", tt.description, tt.pattern, err, tt.expectedOp, tt.faultPath)
+		}
+	}
+}
+
+func checkIOError(t *testing.T, description, fn, pattern, path, op string, err error) {
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || pathErr.Path != path || pathErr.Op != op || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("%v: %v(%#q, WithFailOnIOErrors) has error %v - should be a %v permission error for %v", description, fn, pattern, err, op, path)
+	}
+}
+
+func TestCollectIOErrorsIsAs(t *testing.T) {
+	// errors.Is() and errors.As() only use Unwrap() []error since go 1.20, so
+	// call the error's own Is() and As() to check they work on older versions
+	fsys := faultfs.New(memfs.New().File("b/c.go", nil)).Fail("b", faultfs.OpReadDir, fs.ErrPermission)
+	_, err := doublestar.Glob(fsys, "b/*", doublestar.WithCollectIOErrors())
+
+	isAs, ok := err.(interface {
+		Is(error) bool
+		As(interface{}) bool
+	})
+	if !ok {
+		t.Fatalf("Glob(`b/*`, WithCollectIOErrors) has error %#v - should have Is() and As() methods", err)
+	}
+	if !isAs.Is(fs.ErrPermission) || isAs.Is(fs.ErrNotExist) {
+		t.Errorf("Glob(`b/*`, WithCollectIOErrors) has error %v - Is() should only be true for fs.ErrPermission", err)
+	}
+	var ioErr *doublestar.GlobIOError
+	if !isAs.As(&ioErr) || ioErr.Path != "b" {
+		t.Errorf("Glob(`b/*`, WithCollectIOErrors) has error %v - As() should find a GlobIOError for b", err)
+	}
+	var pathErr *fs.PathError
+	if !isAs.As(&pathErr) || pathErr.Op != "readdir" {
+		t.Errorf("Glob(`b/*`, WithCollectIOErrors) has error %v - As() should find the underlying *fs.PathError", err)
+	}
+}
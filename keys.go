@@ -0,0 +1,220 @@
+package doublestar
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// KeyEntry is a key, or a common prefix of keys, returned from a Lister.
+type KeyEntry struct {
+	// Key is the key or, if IsPrefix is true, the common prefix, including the
+	// trailing delimiter.
+	Key string
+
+	// IsPrefix is true if this entry is a common prefix of several keys, like a
+	// directory, rather than a key.
+	IsPrefix bool
+}
+
+// Lister lists keys in an object store, such as S3, where "directories" are
+// just key prefixes. See GlobKeys().
+type Lister interface {
+	// List returns every key that starts with `prefix`. If `delimiter` is not
+	// empty, keys that contain `delimiter` after `prefix` are rolled up into a
+	// single entry with IsPrefix set, whose Key is everything up to and
+	// including the first `delimiter` after `prefix`, like S3's ListObjectsV2.
+	// The entries may be in any order. If the store returns results a page at a
+	// time, List should return every page.
+	List(ctx context.Context, prefix, delimiter string) ([]KeyEntry, error)
+}
+
+// GlobKeys returns the keys from `l` that match `pattern`, sorted, or nil if
+// there are none. The syntax of pattern is the same as in Match(), with `/` as
+// the path separator.
+//
+// GlobKeys keeps the number of calls to List() to a minimum: literal path
+// segments (including any alternatives before the first wildcard, which are
+// expanded) are added to the prefix without listing, a segment with a
+// wildcard lists with its literal prefix and a `/` delimiter, and only the
+// segments from a `**` onward are listed without a delimiter. Keys that end in
+// `/` (which some tools use as directory markers) are only returned if the
+// pattern matches them, like any other key.
+//
+// GlobKeys returns ErrBadPattern if the pattern is malformed, or the first
+// error returned by List(), if any.
+//
+func GlobKeys(ctx context.Context, l Lister, pattern string) ([]string, error) {
+	if !ValidatePattern(pattern) {
+		return nil, ErrBadPattern
+	}
+
+	k := &keyGlob{
+		ctx:     ctx,
+		lister:  l,
+		pattern: pattern,
+		listed:  make(map[[2]string][]KeyEntry),
+		found:   make(map[string]bool),
+	}
+	for _, alt := range expandLeadingAlts(pattern) {
+		if err := k.glob("", alt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(k.found) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(k.found))
+	for key := range k.found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+type keyGlob struct {
+	ctx     context.Context
+	lister  Lister
+	pattern string
+
+	// results of List(), by prefix and delimiter, so that alternatives don't
+	// list the same thing twice
+	listed map[[2]string][]KeyEntry
+
+	found map[string]bool
+}
+
+// Finds keys that start with `dir` (which is empty or ends in `/`) and then
+// match `pattern`.
+func (k *keyGlob) glob(dir, pattern string) error {
+	segEnd, ok := indexKeySegmentEnd(pattern)
+	seg := pattern
+	if segEnd != -1 {
+		seg = pattern[:segEnd]
+	}
+
+	metaIdx := indexMeta(seg)
+	if !ok || seg == "**" {
+		// list everything from here on
+		prefix := dir + Unescape(pattern[:indexMeta(pattern)])
+		if pattern == "**" {
+			// `a/**` matches `a`
+			prefix = strings.TrimSuffix(prefix, "/")
+		}
+		return k.listAndAdd(prefix, "", false)
+	}
+
+	if metaIdx == -1 {
+		lit := Unescape(seg)
+		if segEnd == -1 {
+			return k.listAndAdd(dir+lit, "/", true)
+		}
+		return k.glob(dir+lit+"/", pattern[segEnd+1:])
+	}
+
+	entries, err := k.list(dir+Unescape(seg[:metaIdx]), "/")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Key, dir) {
+			continue
+		}
+		if segEnd == -1 {
+			if !e.IsPrefix {
+				k.add(e.Key)
+			}
+			continue
+		}
+
+		name := strings.TrimSuffix(e.Key[len(dir):], "/")
+		if e.IsPrefix && matchesSegment(seg, name) {
+			if err = k.glob(e.Key, pattern[segEnd+1:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Lists `prefix` and adds any keys that match the pattern. If `exact` is true,
+// only the key `prefix` itself is considered.
+func (k *keyGlob) listAndAdd(prefix, delimiter string, exact bool) error {
+	entries, err := k.list(prefix, delimiter)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.IsPrefix && (!exact || e.Key == prefix) {
+			k.add(e.Key)
+		}
+	}
+	return nil
+}
+
+func (k *keyGlob) list(prefix, delimiter string) ([]KeyEntry, error) {
+	key := [2]string{prefix, delimiter}
+	if entries, ok := k.listed[key]; ok {
+		return entries, nil
+	}
+
+	entries, err := k.lister.List(k.ctx, prefix, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	k.listed[key] = entries
+	return entries, nil
+}
+
+// Adds `key` to the results if it matches the whole pattern. Listing only
+// narrows down the candidates - this is what decides.
+func (k *keyGlob) add(key string) {
+	if ok, _ := matchWithSeparator(k.pattern, key, '/', false); ok {
+		k.found[key] = true
+	}
+}
+
+func matchesSegment(seg, name string) bool {
+	ok, _ := matchWithSeparator(seg, name, '/', false)
+	return ok
+}
+
+// Returns the index of the first unescaped `/` in `p` that isn't inside of an
+// alternation or character class, or -1 if there isn't one. Returns false if
+// there is a `/` inside of an alternation before then, which means that the
+// pattern can't be split into segments at this point.
+func indexKeySegmentEnd(p string) (int, bool) {
+	alts := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			// skip next byte
+			i++
+
+		case '[':
+			j := i + 1
+			if j < len(p) && (p[j] == '^' || p[j] == '!') {
+				j++
+			}
+			if closing := indexUnescapedByte(p[j:], ']', true); closing != -1 {
+				i = j + closing
+			}
+
+		case '{':
+			alts++
+
+		case '}':
+			if alts > 0 {
+				alts--
+			}
+
+		case '/':
+			if alts > 0 {
+				return -1, false
+			}
+			return i, true
+		}
+	}
+	return -1, true
+}
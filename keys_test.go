@@ -0,0 +1,121 @@
+package doublestar
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// An in-memory stand-in for an object store that records each call to List().
+type testLister struct {
+	keys  []string
+	calls []string
+}
+
+func (l *testLister) List(ctx context.Context, prefix, delimiter string) ([]KeyEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.calls = append(l.calls, prefix+"|"+delimiter)
+
+	var entries []KeyEntry
+	seen := make(map[string]bool)
+	for _, key := range l.keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(key[len(prefix):], delimiter); idx != -1 {
+				common := key[:len(prefix)+idx+len(delimiter)]
+				if !seen[common] {
+					seen[common] = true
+					entries = append(entries, KeyEntry{Key: common, IsPrefix: true})
+				}
+				continue
+			}
+		}
+		entries = append(entries, KeyEntry{Key: key})
+	}
+	return entries, nil
+}
+
+var testKeys = []string{
+	"README",
+	"data/a.csv",
+	"data/x/y/z.csv",
+	"logs/",
+	"logs/2023-01/",
+	"logs/2023-01/app.log",
+	"logs/2023-01/db.log",
+	"logs/2023-02/app.log",
+	"logs/2024-01/app.log",
+	"logs/2024-01/app.log.1",
+}
+
+func TestGlobKeys(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+		calls    []string
+	}{
+		{"README", []string{"README"}, []string{"README|/"}},
+		{"MISSING", nil, []string{"MISSING|/"}},
+		{"logs/2023-*/app.log", []string{"logs/2023-01/app.log", "logs/2023-02/app.log"}, []string{"logs/2023-|/", "logs/2023-01/app.log|/", "logs/2023-02/app.log|/"}},
+		{"logs/*/*.log", []string{"logs/2023-01/app.log", "logs/2023-01/db.log", "logs/2023-02/app.log", "logs/2024-01/app.log"}, []string{"logs/|/", "logs/2023-01/|/", "logs/2023-02/|/", "logs/2024-01/|/"}},
+		{"data/**/*.csv", []string{"data/a.csv", "data/x/y/z.csv"}, []string{"data/|"}},
+		{"data/**", []string{"data/a.csv", "data/x/y/z.csv"}, []string{"data|"}},
+		{"logs/202{3-01,4-01}/app.log", []string{"logs/2023-01/app.log", "logs/2024-01/app.log"}, []string{"logs/2023-01/app.log|/", "logs/2024-01/app.log|/"}},
+		{"{data,logs}/*.csv", []string{"data/a.csv"}, []string{"data/|/", "logs/|/"}},
+		{"logs/*/{app,x/y}.log", []string{"logs/2023-01/app.log", "logs/2023-02/app.log", "logs/2024-01/app.log"}, []string{"logs/|/", "logs/2023-01/|", "logs/2023-02/|", "logs/2024-01/|"}},
+		{"logs/*/", []string{"logs/2023-01/"}, []string{"logs/|/", "logs/2023-01/|/", "logs/2023-02/|/", "logs/2024-01/|/"}},
+		{"**/app.log", []string{"logs/2023-01/app.log", "logs/2023-02/app.log", "logs/2024-01/app.log"}, []string{"|"}},
+		{"logs/[2]023-0[!1]/*", []string{"logs/2023-02/app.log"}, []string{"logs/|/", "logs/2023-02/|/"}},
+	}
+
+	for _, tt := range tests {
+		l := &testLister{keys: testKeys}
+		keys, err := GlobKeys(context.Background(), l, tt.pattern)
+		if err != nil || !reflect.DeepEqual(keys, tt.expected) {
+			t.Errorf("GlobKeys(%#q) = %#v, %v - should be %#v", tt.pattern, keys, err, tt.expected)
+		}
+
+		sort.Strings(l.calls)
+		sort.Strings(tt.calls)
+		if !reflect.DeepEqual(l.calls, tt.calls) {
+			t.Errorf("GlobKeys(%#q) called List with %#v - should be %#v", tt.pattern, l.calls, tt.calls)
+		}
+	}
+}
+
+func TestGlobKeysErrors(t *testing.T) {
+	l := &testLister{keys: testKeys}
+	if _, err := GlobKeys(context.Background(), l, "logs/["); err != ErrBadPattern {
+		t.Errorf("GlobKeys(`logs/[`) has error %v - should be ErrBadPattern", err)
+	}
+	if len(l.calls) != 0 {
+		t.Errorf("GlobKeys(`logs/[`) called List %d times - should be 0", len(l.calls))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if keys, err := GlobKeys(ctx, l, "logs/**"); !errors.Is(err, context.Canceled) || keys != nil {
+		t.Errorf("GlobKeys(`logs/**`) with a canceled context = %#v, %v - should be context.Canceled", keys, err)
+	}
+}
+
+func TestGlobKeysMatchTests(t *testing.T) {
+	for idx, tt := range matchTests {
+		if tt.expectedErr != nil || strings.Contains(tt.testPath, "\\") {
+			continue
+		}
+
+		keys, err := GlobKeys(context.Background(), &testLister{keys: []string{tt.testPath}}, tt.pattern)
+		matched := len(keys) == 1 && keys[0] == tt.testPath
+		if err != nil || matched != tt.shouldMatch {
+			t.Errorf("#%v. GlobKeys(%#q) with key %#q = %#v, %v - should match: %v", idx, tt.pattern, tt.testPath, keys, err, tt.shouldMatch)
+		}
+	}
+}
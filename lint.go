@@ -0,0 +1,181 @@
+package doublestar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+// LintCode identifies the kind of problem reported by a Diagnostic.
+type LintCode string
+
+const (
+	// LintBadPattern is reported if the pattern is malformed. No other
+	// diagnostics are reported in that case.
+	LintBadPattern LintCode = "bad-pattern"
+
+	// LintMidSegmentDoubleStar is reported for a `**` that isn't the only
+	// thing in its path segment, such as `path**`. It behaves like `*`.
+	LintMidSegmentDoubleStar LintCode = "mid-segment-doublestar"
+
+	// LintRedundantDoubleStar is reported for `**/**`, which is the same as
+	// `**`.
+	LintRedundantDoubleStar LintCode = "redundant-doublestar"
+
+	// LintDuplicateAlternative is reported when an alternative appears more
+	// than once in the same `{...}`.
+	LintDuplicateAlternative LintCode = "duplicate-alternative"
+
+	// LintEmptyAlternative is reported for an empty alternative, such as
+	// `{a,}`, which may or may not be intentional.
+	LintEmptyAlternative LintCode = "empty-alternative"
+
+	// LintReversedRange is reported for a range in a character class whose
+	// bounds are reversed, such as `[z-a]`. It only matches its first character.
+	LintReversedRange LintCode = "reversed-range"
+
+	// LintDotSegment is reported for a `.` or `..` path segment. Glob() will
+	// silently return no results for these patterns.
+	LintDotSegment LintCode = "dot-segment"
+
+	// LintLeadingSeparator is reported for a pattern starting with `/`. Glob()
+	// will silently return no results for these patterns.
+	LintLeadingSeparator LintCode = "leading-separator"
+)
+
+// Diagnostic describes a problem found by Lint. `Pos` and `End` are the byte
+// offsets of the problem in the pattern.
+type Diagnostic struct {
+	Pos, End int
+	Code     LintCode
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d-%d: %s (%s)", d.Pos, d.End, d.Message, d.Code)
+}
+
+// Lint checks a pattern for constructs that are valid, but probably don't do
+// what the author intended, such as a mid-segment `**` (which behaves like
+// `*`), redundant `**/**`, duplicate or empty alternatives, character class
+// ranges with reversed bounds, or `.` and `..` segments and a leading `/`
+// (which Glob() silently rejects). See LintCode for all of the checks.
+//
+// Lint assumes the pattern uses `/` as the path separator. If the pattern is
+// malformed, Lint returns a single Diagnostic with the code LintBadPattern.
+// Otherwise, the returned diagnostics are sorted by position. If there are no
+// problems, Lint returns nil.
+//
+func Lint(pattern string) []Diagnostic {
+	root, err := syntax.Parse(pattern)
+	if err != nil {
+		var synErr *syntax.Error
+		if errors.As(err, &synErr) {
+			return []Diagnostic{{synErr.Pos, len(pattern), LintBadPattern, synErr.Msg}}
+		}
+		return []Diagnostic{{0, len(pattern), LintBadPattern, err.Error()}}
+	}
+
+	l := &linter{}
+	l.lintSequence(root, nil, nil)
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		return l.diagnostics[i].Pos < l.diagnostics[j].Pos
+	})
+	return l.diagnostics
+}
+
+type linter struct {
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(pos, end int, code LintCode, format string, args ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{pos, end, code, fmt.Sprintf(format, args...)})
+}
+
+// Lints the children of a Sequence. `before` and `after` are the nodes
+// immediately before and after the Sequence, or nil if the Sequence is at the
+// start or end of the pattern, respectively. This allows us to figure out
+// where path segments start and end, even inside of alternatives.
+func (l *linter) lintSequence(seq, before, after *syntax.Node) {
+	children := seq.Children
+	for i, n := range children {
+		prev, next := before, after
+		if i > 0 {
+			prev = children[i-1]
+		}
+		if i < len(children)-1 {
+			next = children[i+1]
+		}
+
+		switch n.Kind {
+		case syntax.DoubleStar:
+			if isInSegment(prev) || isInSegment(next) {
+				l.report(n.Pos, n.End, LintMidSegmentDoubleStar, "`**` is not the only thing in its path segment, so it behaves like `*`")
+			} else if i >= 2 && children[i-1].Kind == syntax.Separator && children[i-2].Kind == syntax.DoubleStar {
+				prev2 := before
+				if i >= 3 {
+					prev2 = children[i-3]
+				}
+				if !isInSegment(prev2) {
+					l.report(children[i-2].Pos, n.End, LintRedundantDoubleStar, "`**/**` is the same as `**`")
+				}
+			}
+
+		case syntax.Separator:
+			if prev == nil {
+				l.report(n.Pos, n.End, LintLeadingSeparator, "pattern starts with `/`, so Glob will return no results; use SplitPattern or OSGlob instead")
+			}
+
+		case syntax.Literal:
+			if (n.Text == "." || n.Text == "..") && isSegmentBoundary(prev) && isSegmentBoundary(next) {
+				l.report(n.Pos, n.End, LintDotSegment, "`%s` path segment, so Glob will return no results; use SplitPattern or OSGlob instead", n.Text)
+			}
+
+		case syntax.Class:
+			for _, r := range n.Ranges {
+				if r.Lo > r.Hi {
+					l.report(n.Pos, n.End, LintReversedRange, "range `%c-%c` has reversed bounds and will only match `%c`", r.Lo, r.Hi, r.Lo)
+				}
+			}
+
+		case syntax.Alternation:
+			l.lintAlternation(n, prev, next)
+		}
+	}
+}
+
+func (l *linter) lintAlternation(n, before, after *syntax.Node) {
+	seen := make(map[string]bool, len(n.Children))
+	for _, alt := range n.Children {
+		if len(alt.Children) == 0 {
+			l.report(alt.Pos, alt.End, LintEmptyAlternative, "empty alternative makes the rest of the alternation optional; is that intentional?")
+		} else if s := alt.String(); seen[s] {
+			l.report(alt.Pos, alt.End, LintDuplicateAlternative, "alternative `%s` appears more than once", s)
+		} else {
+			seen[s] = true
+		}
+
+		l.lintSequence(alt, before, after)
+	}
+}
+
+// Returns true if `n` is definitely part of the same path segment as an
+// adjacent node. Alternations may or may not be, so they return false.
+func isInSegment(n *syntax.Node) bool {
+	if n == nil {
+		return false
+	}
+	switch n.Kind {
+	case syntax.Literal, syntax.Star, syntax.DoubleStar, syntax.Any, syntax.Class:
+		return true
+	}
+	return false
+}
+
+// Returns true if `n` definitely ends a path segment - that is, it's a
+// separator or the start or end of the pattern.
+func isSegmentBoundary(n *syntax.Node) bool {
+	return n == nil || n.Kind == syntax.Separator
+}
@@ -0,0 +1,74 @@
+package doublestar
+
+import "testing"
+
+type LintTest struct {
+	pattern  string     // pattern to lint
+	expected []LintCode // expected diagnostic codes, in order
+}
+
+var lintTests = []LintTest{
+	{"", nil},
+	{"a/**/b/*.txt", nil},
+	{"**", nil},
+	{"a/{b,c}/**", nil},
+	{"a[", []LintCode{LintBadPattern}},
+	{"a}", []LintCode{LintBadPattern}},
+	{"path**", []LintCode{LintMidSegmentDoubleStar}},
+	{"path/to/**.txt", []LintCode{LintMidSegmentDoubleStar}},
+	{"***", []LintCode{LintMidSegmentDoubleStar}},
+	{"a/**b/c", []LintCode{LintMidSegmentDoubleStar}},
+	{"a/{b**,c}", []LintCode{LintMidSegmentDoubleStar}},
+	{"a/{**,c}/d", nil},
+	{"**/**", []LintCode{LintRedundantDoubleStar}},
+	{"a/**/**/b", []LintCode{LintRedundantDoubleStar}},
+	{"a/**/**/**", []LintCode{LintRedundantDoubleStar, LintRedundantDoubleStar}},
+	{"a**/**", []LintCode{LintMidSegmentDoubleStar}},
+	{"{a,b,a}", []LintCode{LintDuplicateAlternative}},
+	{"{a/*,b,a/*}", []LintCode{LintDuplicateAlternative}},
+	{"{a,{b,b}}", []LintCode{LintDuplicateAlternative}},
+	{"a{,bc}", []LintCode{LintEmptyAlternative}},
+	{"a{b,,c}", []LintCode{LintEmptyAlternative}},
+	{"[z-a]", []LintCode{LintReversedRange}},
+	{"[a-z]", nil},
+	{"[a-a]", nil},
+	{"./a", []LintCode{LintDotSegment}},
+	{"a/../b", []LintCode{LintDotSegment}},
+	{"a/.", []LintCode{LintDotSegment}},
+	{"a/{.,b}/c", []LintCode{LintDotSegment}},
+	{"a/.b/..c", nil},
+	{"/a/*", []LintCode{LintLeadingSeparator}},
+	{"{/a,/b}/*", []LintCode{LintLeadingSeparator, LintLeadingSeparator}},
+	{"a/{/b,c}", nil},
+	{"/../**/**/[b-a]", []LintCode{LintLeadingSeparator, LintDotSegment, LintRedundantDoubleStar, LintReversedRange}},
+}
+
+func TestLint(t *testing.T) {
+	for idx, tt := range lintTests {
+		diagnostics := Lint(tt.pattern)
+		if len(diagnostics) != len(tt.expected) {
+			t.Errorf("#%v. Lint(%#q) = %v - should have codes %v", idx, tt.pattern, diagnostics, tt.expected)
+			continue
+		}
+		for i, d := range diagnostics {
+			if d.Code != tt.expected[i] {
+				t.Errorf("#%v. Lint(%#q) = %v - should have codes %v", idx, tt.pattern, diagnostics, tt.expected)
+				break
+			}
+			if d.Pos < 0 || d.End > len(tt.pattern) || d.Pos > d.End {
+				t.Errorf("#%v. Lint(%#q) = %v - has bad positions", idx, tt.pattern, diagnostics)
+			}
+		}
+	}
+}
+
+func TestLintMatchTests(t *testing.T) {
+	// Lint should agree with ValidatePattern, and never panic
+	for idx, tt := range matchTests {
+		diagnostics := Lint(tt.pattern)
+		hasBadPattern := len(diagnostics) == 1 && diagnostics[0].Code == LintBadPattern
+		if hasBadPattern != (tt.expectedErr != nil) {
+			t.Errorf("#%v. Lint(%#q) = %v - bad pattern should be %v", idx, tt.pattern, diagnostics, tt.expectedErr != nil)
+		}
+	}
+}
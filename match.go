@@ -3,6 +3,8 @@ package doublestar
 import (
 	"path/filepath"
 	"unicode/utf8"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
 )
 
 // Match reports whether name matches the shell pattern.
@@ -68,16 +70,20 @@ func PathMatch(pattern, name string) (bool, error) {
 }
 
 func matchWithSeparator(pattern, name string, separator rune, validate bool) (matched bool, err error) {
-	return doMatchWithSeparator(pattern, name, separator, validate, -1, -1, -1, -1, 0, 0)
+	return doMatchWithSeparator(pattern, name, separator, validate, -1, -1, -1, -1, 0, 0, nil)
 }
 
-func doMatchWithSeparator(pattern, name string, separator rune, validate bool, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, patIdx, nameIdx int) (matched bool, err error) {
+// If `tr` is not nil, the match is recorded in it for Explain().
+func doMatchWithSeparator(pattern, name string, separator rune, validate bool, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, patIdx, nameIdx int, tr *matchTrace) (matched bool, err error) {
 	patLen := len(pattern)
 	nameLen := len(name)
 	startOfSegment := true
 MATCH:
 	for nameIdx < nameLen {
 		if patIdx < patLen {
+			if tr != nil {
+				tr.tokenStart = patIdx
+			}
 			switch pattern[patIdx] {
 			case '*':
 				if patIdx++; patIdx < patLen && pattern[patIdx] == '*' {
@@ -87,6 +93,9 @@ MATCH:
 					if startOfSegment {
 						if patIdx >= patLen {
 							// pattern ends in `/**`: return true
+							if tr != nil {
+								tr.doublestarStep = tr.add(syntax.DoubleStar, patIdx, nameIdx, nameLen)
+							}
 							return true, nil
 						}
 
@@ -100,6 +109,9 @@ MATCH:
 							doublestarNameBacktrack = nameIdx
 							starPatternBacktrack = -1
 							starNameBacktrack = -1
+							if tr != nil {
+								tr.doublestarStep = tr.add(syntax.DoubleStar, patIdx, nameIdx, nameIdx)
+							}
 							continue
 						}
 					}
@@ -108,6 +120,9 @@ MATCH:
 
 				starPatternBacktrack = patIdx
 				starNameBacktrack = nameIdx
+				if tr != nil {
+					tr.starStep = tr.add(syntax.Star, patIdx, nameIdx, nameIdx)
+				}
 				continue
 
 			case '?':
@@ -120,6 +135,9 @@ MATCH:
 
 				patIdx++
 				nameIdx += nameRuneLen
+				if tr != nil {
+					tr.add(syntax.Any, patIdx, nameIdx-nameRuneLen, nameIdx)
+				}
 				continue
 
 			case '[':
@@ -198,6 +216,9 @@ MATCH:
 
 				patIdx += closingIdx + 1
 				nameIdx += nameRuneLen
+				if tr != nil {
+					tr.add(syntax.Class, patIdx, nameIdx-nameRuneLen, nameIdx)
+				}
 				continue
 
 			case '{':
@@ -218,14 +239,24 @@ MATCH:
 					}
 					commaIdx += patIdx
 
-					result, err := doMatchWithSeparator(pattern[:beforeIdx]+pattern[patIdx:commaIdx]+pattern[closingIdx+1:], name, separator, validate, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, beforeIdx, nameIdx)
+					var saved matchTraceState
+					if tr != nil {
+						saved = tr.enterAlt(pattern, beforeIdx, patIdx, commaIdx, closingIdx)
+					}
+					result, err := doMatchWithSeparator(pattern[:beforeIdx]+pattern[patIdx:commaIdx]+pattern[closingIdx+1:], name, separator, validate, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, beforeIdx, nameIdx, tr)
 					if result || err != nil {
 						return result, err
 					}
+					if tr != nil {
+						tr.restore(saved)
+					}
 
 					patIdx = commaIdx + 1
 				}
-				return doMatchWithSeparator(pattern[:beforeIdx]+pattern[patIdx:closingIdx]+pattern[closingIdx+1:], name, separator, validate, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, beforeIdx, nameIdx)
+				if tr != nil {
+					tr.enterAlt(pattern, beforeIdx, patIdx, closingIdx, closingIdx)
+				}
+				return doMatchWithSeparator(pattern[:beforeIdx]+pattern[patIdx:closingIdx]+pattern[closingIdx+1:], name, separator, validate, doublestarPatternBacktrack, doublestarNameBacktrack, starPatternBacktrack, starNameBacktrack, beforeIdx, nameIdx, tr)
 
 			case '\\':
 				if separator != '\\' {
@@ -252,10 +283,26 @@ MATCH:
 				patIdx += patRuneLen
 				nameIdx += nameRuneLen
 				startOfSegment = patRune == separator
+				if tr != nil {
+					kind := syntax.Literal
+					if startOfSegment {
+						kind = syntax.Separator
+					}
+					tr.add(kind, patIdx, nameIdx-nameRuneLen, nameIdx)
+				}
 				continue
 			}
 		}
 
+		if tr != nil {
+			// a failed character class may leave patIdx in the middle of the class
+			failIdx := patIdx
+			if patIdx < patLen {
+				failIdx = tr.tokenStart
+			}
+			tr.fail(failIdx, nameIdx)
+		}
+
 		if starPatternBacktrack >= 0 {
 			// `*` backtrack, but only if the `name` rune isn't the separator
 			nameRune, nameRuneLen := utf8.DecodeRuneInString(name[starNameBacktrack:])
@@ -264,6 +311,9 @@ MATCH:
 				patIdx = starPatternBacktrack
 				nameIdx = starNameBacktrack
 				startOfSegment = false
+				if tr != nil {
+					tr.backtrack(tr.starStep, nameIdx)
+				}
 				continue
 			}
 		}
@@ -278,6 +328,9 @@ MATCH:
 					doublestarNameBacktrack = nameIdx
 					patIdx = doublestarPatternBacktrack
 					startOfSegment = true
+					if tr != nil {
+						tr.backtrack(tr.doublestarStep, nameIdx)
+					}
 					continue MATCH
 				}
 			}
@@ -297,7 +350,11 @@ MATCH:
 	// we've reached the end of `name`; we've successfully matched if we've also
 	// reached the end of `pattern`, or if the rest of `pattern` can match a
 	// zero-length string
-	return isZeroLengthPattern(pattern[patIdx:], separator)
+	matched, err = isZeroLengthPattern(pattern[patIdx:], separator)
+	if tr != nil && !matched {
+		tr.fail(patIdx, nameIdx)
+	}
+	return
 }
 
 func isZeroLengthPattern(pattern string, separator rune) (ret bool, err error) {
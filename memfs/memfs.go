@@ -0,0 +1,413 @@
+// Package memfs provides an in-memory filesystem for testing globs.
+//
+// Unlike testing/fstest.MapFS, an FS supports symlinks (including broken
+// links and loops) and injected errors, and it is built with a fluent
+// builder, so a test can reproduce awkward trees deterministically:
+//
+//   fsys := memfs.New().
+//     File("a/b.txt", []byte("hello")).
+//     Dir("c").
+//     Symlink("d", "c").
+//     Error("e", fs.ErrPermission)
+//
+// An FS implements fs.FS, fs.ReadDirFS, and fs.StatFS, as well as ReadLink()
+// and Lstat() methods, which match fs.ReadLinkFS from newer versions of Go.
+package memfs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotDir is returned when a path that isn't a directory is used as one.
+var ErrNotDir = errors.New("not a directory")
+
+// ErrLoop is returned when too many symlinks are followed while resolving a
+// path, which usually means there is a symlink loop.
+var ErrLoop = errors.New("too many levels of symbolic links")
+
+// The maximum number of symlinks followed while resolving a path, which is the
+// same as Linux.
+const maxLinks = 40
+
+// FS is an in-memory filesystem. See New().
+//
+// The builder methods may be called at any time, even while the FS is being
+// used by another goroutine. They panic if `name` isn't a valid path (see
+// fs.ValidPath()) or if it would need to go through a file.
+type FS struct {
+	mu   sync.RWMutex
+	root *node
+}
+
+type node struct {
+	mode     fs.FileMode
+	data     []byte
+	target   string
+	modTime  time.Time
+	children map[string]*node
+	err      error
+}
+
+// New creates an empty FS.
+func New() *FS {
+	return &FS{root: newDir()}
+}
+
+func newDir() *node {
+	return &node{mode: fs.ModeDir | 0755, children: make(map[string]*node)}
+}
+
+// File creates or replaces the file `name` with a copy of `data`, creating any
+// missing parent directories.
+func (f *FS) File(name string, data []byte) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(name, &node{mode: 0644, data: append([]byte(nil), data...)})
+	return f
+}
+
+// Dir creates the directory `name`, and any missing parent directories, if it
+// doesn't already exist.
+func (f *FS) Dir(name string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mkdirAll("Dir", name)
+	return f
+}
+
+// Symlink creates or replaces `name` with a symlink to `target`, creating any
+// missing parent directories. Like symlinks on disk, `target` is relative to
+// the directory containing `name`, unless it starts with a `/`, in which case
+// it is relative to the root of the FS. `target` doesn't need to exist.
+func (f *FS) Symlink(name, target string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(name, &node{mode: fs.ModeSymlink | 0777, target: target})
+	return f
+}
+
+// Error makes every operation on `name`, or anything below it, fail with
+// `err`, such as fs.ErrPermission. `name` must already exist. Passing a nil
+// `err` removes the error.
+func (f *FS) Error(name string, err error) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mustFind("Error", name).err = err
+	return f
+}
+
+// Touch sets the modification time of `name`, which must already exist. By
+// default, everything has the zero time.Time as its modification time.
+func (f *FS) Touch(name string, modTime time.Time) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mustFind("Touch", name).modTime = modTime
+	return f
+}
+
+// Remove removes `name` and anything below it, if it exists.
+func (f *FS) Remove(name string) *FS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if name == "." {
+		panic("memfs: cannot remove the root directory")
+	}
+	if dir := f.find("Remove", path.Dir(name)); dir != nil && dir.children != nil {
+		delete(dir.children, path.Base(name))
+	}
+	return f
+}
+
+// Puts `n` at `name`, replacing anything that's already there.
+func (f *FS) set(name string, n *node) {
+	if name == "." {
+		panic("memfs: cannot replace the root directory")
+	}
+	f.mkdirAll("set", path.Dir(name)).children[path.Base(name)] = n
+}
+
+// Creates the directory `name` and any missing parents, without following
+// symlinks, and returns it - f.mu must be held.
+func (f *FS) mkdirAll(op, name string) *node {
+	if !fs.ValidPath(name) {
+		panic("memfs: " + op + "(" + name + "): invalid path")
+	}
+
+	dir := f.root
+	if name == "." {
+		return dir
+	}
+	for _, c := range strings.Split(name, "/") {
+		child := dir.children[c]
+		if child == nil {
+			child = newDir()
+			dir.children[c] = child
+		} else if !child.mode.IsDir() {
+			panic("memfs: " + op + "(" + name + "): " + c + " is not a directory")
+		}
+		dir = child
+	}
+	return dir
+}
+
+// Returns the node at `name`, without following symlinks, or nil if it
+// doesn't exist - f.mu must be held.
+func (f *FS) find(op, name string) *node {
+	if !fs.ValidPath(name) {
+		panic("memfs: " + op + "(" + name + "): invalid path")
+	}
+
+	n := f.root
+	if name == "." {
+		return n
+	}
+	for _, c := range strings.Split(name, "/") {
+		if n = n.children[c]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// Like find(), but panics if `name` doesn't exist.
+func (f *FS) mustFind(op, name string) *node {
+	n := f.find(op, name)
+	if n == nil {
+		panic("memfs: " + op + "(" + name + "): does not exist")
+	}
+	return n
+}
+
+// Resolves `name` to a node, following symlinks, except for the last path
+// element if `followLast` is false - f.mu must be held for reading.
+func (f *FS) lookup(op, name string, followLast bool) (*node, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	var parents []*node
+	cur := f.root
+	var queue []string
+	if name != "." {
+		queue = strings.Split(name, "/")
+	}
+	links := 0
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if cur.err != nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: cur.err}
+		}
+		if c == "" || c == "." {
+			continue
+		}
+		if !cur.mode.IsDir() {
+			return nil, &fs.PathError{Op: op, Path: name, Err: ErrNotDir}
+		}
+		if c == ".." {
+			if len(parents) == 0 {
+				// symlinks can't point outside of the FS
+				return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+			}
+			cur = parents[len(parents)-1]
+			parents = parents[:len(parents)-1]
+			continue
+		}
+
+		child := cur.children[c]
+		if child == nil {
+			return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		if child.mode&fs.ModeSymlink != 0 && (len(queue) > 0 || followLast) {
+			if child.err != nil {
+				return nil, &fs.PathError{Op: op, Path: name, Err: child.err}
+			}
+			if links++; links > maxLinks {
+				return nil, &fs.PathError{Op: op, Path: name, Err: ErrLoop}
+			}
+			target := child.target
+			if strings.HasPrefix(target, "/") {
+				cur, parents = f.root, nil
+				target = strings.TrimLeft(target, "/")
+			}
+			queue = append(strings.Split(target, "/"), queue...)
+			continue
+		}
+
+		parents = append(parents, cur)
+		cur = child
+	}
+
+	if cur.err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: cur.err}
+	}
+	return cur, nil
+}
+
+// Open opens the named file or directory, following symlinks.
+func (f *FS) Open(name string) (fs.File, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, err := f.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	info := newFileInfo(name, n)
+	if n.mode.IsDir() {
+		return &openDir{info: info, entries: readDir(n)}, nil
+	}
+	return &openFile{info: info, r: bytes.NewReader(n.data)}, nil
+}
+
+// ReadDir reads the named directory, following symlinks, and returns its
+// entries sorted by name. Symlinks in the directory are not followed: their
+// entries have a type of fs.ModeSymlink.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, err := f.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: ErrNotDir}
+	}
+	return readDir(n), nil
+}
+
+// Stat returns a FileInfo describing the named file, following symlinks.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, err := f.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(name, n), nil
+}
+
+// Lstat returns a FileInfo describing the named file. If it is a symlink, the
+// FileInfo describes the link itself.
+func (f *FS) Lstat(name string) (fs.FileInfo, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, err := f.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(name, n), nil
+}
+
+// ReadLink returns the target of the named symlink, exactly as it was passed
+// to Symlink().
+func (f *FS) ReadLink(name string) (string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n, err := f.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if n.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return n.target, nil
+}
+
+// Returns the entries of a directory node, sorted by name - the FS's mu must
+// be held for reading.
+func readDir(n *node) []fs.DirEntry {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]fs.DirEntry, len(names))
+	for i, name := range names {
+		entries[i] = newFileInfo(name, n.children[name])
+	}
+	return entries
+}
+
+// fileInfo implements fs.FileInfo and fs.DirEntry. It copies everything it
+// needs from the node, so it doesn't change if the FS does.
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func newFileInfo(name string, n *node) *fileInfo {
+	size := int64(len(n.data))
+	if n.mode&fs.ModeSymlink != 0 {
+		size = int64(len(n.target))
+	}
+	return &fileInfo{name: path.Base(name), size: size, mode: n.mode, modTime: n.modTime}
+}
+
+func (i *fileInfo) Name() string               { return i.name }
+func (i *fileInfo) Size() int64                { return i.size }
+func (i *fileInfo) Mode() fs.FileMode          { return i.mode }
+func (i *fileInfo) ModTime() time.Time         { return i.modTime }
+func (i *fileInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i *fileInfo) Sys() interface{}           { return nil }
+func (i *fileInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i *fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+type openFile struct {
+	info *fileInfo
+	r    *bytes.Reader
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *openFile) Close() error               { return nil }
+
+func (f *openFile) Seek(offset int64, whence int) (int64, error) {
+	return f.r.Seek(offset, whence)
+}
+
+func (f *openFile) ReadAt(b []byte, offset int64) (int, error) {
+	return f.r.ReadAt(b, offset)
+}
+
+type openDir struct {
+	info    *fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *openDir) Close() error               { return nil }
+
+func (d *openDir) Read(b []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *openDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if count > 0 {
+		if len(rest) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(rest) {
+			rest = rest[:count]
+		}
+	}
+	d.offset += len(rest)
+	return rest, nil
+}
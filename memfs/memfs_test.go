@@ -0,0 +1,162 @@
+package memfs_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+func newTestFS() *memfs.FS {
+	return memfs.New().
+		File("a/b.txt", []byte("hello")).
+		File("a/c/d.go", nil).
+		Dir("e").
+		Symlink("f", "a").
+		Symlink("a/c/up", "../b.txt").
+		Symlink("g", "/a/c").
+		Symlink("broken", "nope")
+}
+
+func TestFS(t *testing.T) {
+	// fstest.TestFS opens every entry, so it doesn't like broken symlinks
+	fsys := newTestFS().Remove("broken")
+	if err := fstest.TestFS(fsys, "a/b.txt", "a/c/d.go", "a/c/up", "e", "f", "g"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	fsys := newTestFS()
+
+	tests := []struct {
+		name, stat string
+		lstat      fs.FileMode
+		err        error
+	}{
+		{"f", "d", fs.ModeSymlink, nil},
+		{"f/c/up", "-", fs.ModeSymlink, nil},
+		{"g/up", "-", fs.ModeSymlink, nil},
+		{"broken", "", fs.ModeSymlink, fs.ErrNotExist},
+		{"a/b.txt/x", "", 0, memfs.ErrNotDir},
+	}
+	for _, tt := range tests {
+		info, err := fsys.Stat(tt.name)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Stat(%#q) has error %v - should be %v", tt.name, err, tt.err)
+		} else if err == nil && info.Mode().String()[:1] != tt.stat {
+			t.Errorf("Stat(%#q).Mode() = %v - should start with %v", tt.name, info.Mode(), tt.stat)
+		}
+
+		info, err = fsys.Lstat(tt.name)
+		if tt.lstat != 0 && (err != nil || info.Mode().Type() != tt.lstat) {
+			t.Errorf("Lstat(%#q) = %v, %v - should be a symlink", tt.name, info, err)
+		}
+	}
+
+	if target, err := fsys.ReadLink("a/c/up"); target != "../b.txt" || err != nil {
+		t.Errorf("ReadLink(`a/c/up`) = %#q, %v - should be `../b.txt`", target, err)
+	}
+	if _, err := fsys.ReadLink("a/b.txt"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("ReadLink(`a/b.txt`) has error %v - should be fs.ErrInvalid", err)
+	}
+
+	// symlinks can't point outside of the FS
+	fsys.Symlink("out", "../a")
+	if _, err := fsys.Stat("out"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(`out`) has error %v - should be fs.ErrNotExist", err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	fsys := newTestFS()
+	matches, err := doublestar.Glob(fsys, "**/*.go")
+	expected := []string{"a/c/d.go", "f/c/d.go", "g/d.go"}
+	if err != nil || !reflect.DeepEqual(matches, expected) {
+		t.Errorf("Glob(`**/*.go`) = %#v, %v - should be %#v", matches, err, expected)
+	}
+
+	// a symlink loop stops with an error once too many links are followed
+	fsys.Symlink("a/c/loop", "..")
+	var ioErr *doublestar.GlobIOError
+	matches, err = doublestar.Glob(fsys, "a/**/d.go", doublestar.WithCollectIOErrors())
+	if !errors.As(err, &ioErr) || !errors.Is(ioErr, memfs.ErrLoop) || len(matches) != 41 {
+		t.Errorf("Glob(`a/**/d.go`) with a symlink loop = %v results, %v - should have 41 results and memfs.ErrLoop", len(matches), err)
+	}
+}
+
+func TestError(t *testing.T) {
+	fsys := newTestFS().Error("a/c", fs.ErrPermission)
+	for _, name := range []string{"a/c", "a/c/d.go", "g", "f/c/up"} {
+		if _, err := fsys.Stat(name); !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Stat(%#q) has error %v - should be fs.ErrPermission", name, err)
+		}
+	}
+	if _, err := fsys.Stat("a/b.txt"); err != nil {
+		t.Errorf("Stat(`a/b.txt`) has error %v - should not", err)
+	}
+
+	_, err := doublestar.Glob(fsys, "a/**", doublestar.WithFailOnIOErrors())
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Glob(`a/**`, WithFailOnIOErrors) has error %v - should be fs.ErrPermission", err)
+	}
+
+	fsys.Error("a/c", nil)
+	if _, err := fsys.Stat("a/c/d.go"); err != nil {
+		t.Errorf("Stat(`a/c/d.go`) after removing the error has error %v - should not", err)
+	}
+}
+
+func TestRemoveAndTouch(t *testing.T) {
+	fsys := newTestFS()
+	modTime := time.Unix(1234, 0)
+	fsys.Touch("a/b.txt", modTime).Remove("a/c").Remove("a/x/y")
+
+	if info, err := fsys.Stat("a/b.txt"); err != nil || !info.ModTime().Equal(modTime) {
+		t.Errorf("Stat(`a/b.txt`) = %v, %v - should have been touched", info, err)
+	}
+	if _, err := fsys.Stat("a/c/d.go"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat(`a/c/d.go`) has error %v - should be fs.ErrNotExist", err)
+	}
+	if _, err := fsys.Stat("a/x"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Remove(`a/x/y`) created `a/x`")
+	}
+}
+
+func TestBuilderPanics(t *testing.T) {
+	tests := map[string]func(){
+		"File through a file": func() { memfs.New().File("a", nil).File("a/b", nil) },
+		"invalid path":        func() { memfs.New().Dir("/a") },
+		"Error on nothing":    func() { memfs.New().Error("a", fs.ErrPermission) },
+	}
+	for name, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v should have panicked", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestLargeTree(t *testing.T) {
+	fsys := memfs.New()
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 100; j++ {
+			fsys.File(fmt.Sprintf("d%02d/f%02d.txt", i, j), nil)
+		}
+	}
+
+	matches, err := doublestar.Glob(fsys, "d*/f?5.txt")
+	if err != nil || len(matches) != 1000 || matches[0] != "d00/f05.txt" {
+		t.Errorf("Glob(`d*/f?5.txt`) = %v results, %v - should have 1000", len(matches), err)
+	}
+}
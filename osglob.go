@@ -0,0 +1,133 @@
+package doublestar
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// OSGlob returns the names of all files matching pattern or nil if there is
+// no matching file. The syntax of pattern is the same as in Match(). Unlike
+// Glob(), pattern may be absolute (ie, start with `/`) and may contain `.` or
+// `..` path elements. Returned paths will use the system's path separator.
+//
+// OSGlob is a convenience function for patterns that are not relative to any
+// particular fs.FS, such as patterns read from a configuration file.
+// Basically, it:
+//   - Expands any alternatives (`{...}`) that appear before the first `*`, `?`,
+//     or `[`. For example, `{/etc,/opt}/app/*.conf` becomes `/etc/app/*.conf`
+//     and `/opt/app/*.conf`.
+//   - Runs `path.Clean()` on each of the resulting patterns, preserving any
+//     trailing slash.
+//   - Runs `SplitPattern()` on each of those to get a base path and a pattern
+//   - Creates an FS object from each base path and globs on each pattern
+//   - Joins the base path with all of the matches, removing any duplicates
+//
+// Like FilepathGlob, `.` and `..` path elements are resolved lexically by
+// `path.Clean()`, so `a/*/../b` is equivalent to `a/b`.
+//
+// Like Glob(), this function assumes that your pattern uses `/` as the path
+// separator even if that's not correct for your OS (like Windows). If you
+// aren't sure if that's the case, you can use filepath.ToSlash() on your
+// pattern before calling OSGlob().
+//
+// OSGlob ignores file system errors such as I/O errors reading directories by
+// default. The only possible returned error is ErrBadPattern, reporting that
+// the pattern is malformed. OSGlob accepts the same options as Glob(). Paths
+// passed to an ErrorHandler, or recorded in a GlobIOError, use the system's
+// path separator and are joined with the base path, just like the matches.
+//
+// Note: users should _not_ count on the returned error,
+// doublestar.ErrBadPattern, being equal to path.ErrBadPattern.
+//
+func OSGlob(pattern string, opts ...GlobOption) (matches []string, err error) {
+	err = OSGlobWalk(pattern, func(p string, d fs.DirEntry) error {
+		matches = append(matches, p)
+		return nil
+	}, opts...)
+	if err != nil {
+		if _, ok := err.(joinedIOErrors); !ok {
+			return nil, err
+		}
+	}
+	return
+}
+
+// OSGlobWalk calls the callback function `fn` for every file matching pattern.
+// The pattern is handled exactly like OSGlob() and, like OSGlob(), the path
+// passed to `fn` will use the system's path separator. Otherwise, the behavior
+// is the same as GlobWalk(), including support for returning SkipDir from
+// `fn`.
+//
+// If the pattern expands into multiple base paths which overlap, each matching
+// path will still only be passed to `fn` once.
+//
+// Note: users should _not_ count on the returned error,
+// doublestar.ErrBadPattern, being equal to path.ErrBadPattern.
+//
+func OSGlobWalk(pattern string, fn GlobWalkFunc, opts ...GlobOption) error {
+	if !ValidatePattern(pattern) {
+		return ErrBadPattern
+	}
+
+	g := newGlob(opts...)
+	defer g.startStats()()
+	roots := splitPatternRoots(pattern)
+
+	var seen map[string]bool
+	if len(roots) > 1 {
+		seen = make(map[string]bool)
+	}
+
+	fn = g.limitWalkFunc(g.statsWalkFunc(fn))
+	for _, r := range roots {
+		// the base is part of the pattern, so any escaped meta characters in it
+		// (see QuoteMeta) have to be unescaped before it's used as a path
+		base := Unescape(r.Base)
+		g.osBase = base
+		err := g.doGlobWalk(os.DirFS(base), r.Pattern, true, func(p string, d fs.DirEntry) error {
+			// use path.Join because the pattern uses forward slashes, no matter
+			// what the system uses
+			p = filepath.FromSlash(path.Join(base, p))
+			if seen != nil {
+				if seen[p] {
+					return nil
+				}
+				seen[p] = true
+			}
+			return fn(p, d)
+		})
+		if err == errLimitReached {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return g.collectedIOErrors(nil)
+}
+
+// Expands alternatives in the base of `p`, cleans each resulting pattern, and
+// splits them with SplitPattern. Assumes `p` has been validated.
+func splitPatternRoots(p string) []Split {
+	alts := expandLeadingAlts(p)
+	roots := make([]Split, 0, len(alts))
+	for _, alt := range alts {
+		base, pattern := SplitPattern(cleanPattern(alt))
+		roots = append(roots, Split{base, pattern})
+	}
+	return roots
+}
+
+// Runs path.Clean() on a pattern, but preserves a trailing slash since it
+// changes the meaning of the pattern (ie, only match directories).
+func cleanPattern(p string) string {
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
@@ -0,0 +1,162 @@
+package doublestar
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOSGlob(t *testing.T) {
+	for idx, tt := range matchTests {
+		if tt.testOnDisk {
+			testOSGlobWith(t, idx, tt)
+		}
+	}
+}
+
+func testOSGlobWith(t *testing.T, idx int, tt MatchTest) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("#%v. OSGlob(%#q) panicked: %#v", idx, tt.pattern, r)
+		}
+	}()
+
+	// the patterns are relative to the "test" sub-directory
+	pattern := "test/" + tt.pattern
+	testPath := filepath.FromSlash("test/" + tt.testPath)
+	matches, err := OSGlob(pattern)
+
+	numResults := tt.numResults
+	if onWindows {
+		numResults = tt.winNumResults
+	}
+	if len(matches) != numResults {
+		t.Errorf("#%v. OSGlob(%#q) = %#v - should have %#v results, got %#v", idx, pattern, matches, numResults, len(matches))
+	}
+	if inSlice(testPath, matches) != tt.shouldMatch {
+		if tt.shouldMatch {
+			t.Errorf("#%v. OSGlob(%#q) = %#v - doesn't contain %v, but should", idx, pattern, matches, testPath)
+		} else {
+			t.Errorf("#%v. OSGlob(%#q) = %#v - contains %v, but shouldn't", idx, pattern, matches, testPath)
+		}
+	}
+	if err != tt.expectedErr {
+		t.Errorf("#%v. OSGlob(%#q) has error %v, but should be %v", idx, pattern, err, tt.expectedErr)
+	}
+}
+
+type OSGlobTest struct {
+	pattern  string   // pattern to test, relative to the working directory
+	expected []string // expected matches, relative to the working directory
+}
+
+func TestOSGlobRoots(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Could not get working directory: %v", err)
+	}
+	abs := filepath.ToSlash(wd)
+
+	tests := []OSGlobTest{
+		{"test/a/../abc/*", []string{"test/abc/b", "test/abc/【test】.txt"}},
+		{"test/a/*/../../abc/b", []string{"test/abc/b"}},
+		{"test/{a,abc}/b", []string{"test/a/b", "test/abc/b"}},
+		{"test/{a,a}/b", []string{"test/a/b"}},
+		{"{test/a,test/abc}/*/c", []string{"test/a/b/c"}},
+		{"{test/a/b,test/a}/**/d", []string{"test/a/b/c/d"}},
+		{"test/{a/b/*,b*}/", []string{"test/a/b/c", "test/b"}},
+		{abs + "/test/a/c/*", []string{wd + "/test/a/c/b"}},
+		{"{" + abs + "/test/a," + abs + "/test/b}/c", []string{wd + "/test/a/c", wd + "/test/b/c"}},
+		{"test/nonexistent-path/{a,b}/*", nil},
+	}
+
+	for idx, tt := range tests {
+		matches, err := OSGlob(tt.pattern)
+		if err != nil {
+			t.Errorf("#%v. OSGlob(%#q) has error %v, but should not", idx, tt.pattern, err)
+		}
+
+		expected := make([]string, len(tt.expected))
+		for i, e := range tt.expected {
+			expected[i] = filepath.FromSlash(e)
+		}
+		if !compareSlices(matches, expected) {
+			t.Errorf("#%v. OSGlob(%#q) = %#v - should be %#v", idx, tt.pattern, matches, expected)
+		}
+
+		var walked []string
+		err = OSGlobWalk(tt.pattern, func(p string, d fs.DirEntry) error {
+			walked = append(walked, p)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("#%v. OSGlobWalk(%#q) has error %v, but should not", idx, tt.pattern, err)
+		}
+		if !compareSlices(walked, expected) {
+			t.Errorf("#%v. OSGlobWalk(%#q) = %#v - should be %#v", idx, tt.pattern, walked, expected)
+		}
+	}
+}
+
+func TestOSGlobWithLimit(t *testing.T) {
+	matches, err := OSGlob("test/{a,abc,b}/*", WithLimit(3))
+	if err != nil {
+		t.Errorf("OSGlob(`test/{a,abc,b}/*`, WithLimit(3)) has error %v, but should not", err)
+	}
+	if len(matches) != 3 {
+		t.Errorf("OSGlob(`test/{a,abc,b}/*`, WithLimit(3)) = %#v - should have 3 results", matches)
+	}
+}
+
+func TestOSGlobEscapedBase(t *testing.T) {
+	if onWindows {
+		t.Skip("escaping is disabled on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "a{b,c}")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{QuoteMeta(dir) + "/f.txt", QuoteMeta(dir) + "/*.txt"} {
+		matches, err := OSGlob(pattern)
+		if err != nil || len(matches) != 1 || matches[0] != file {
+			t.Errorf("OSGlob(%#q) = %#v, %v - should be %#v", pattern, matches, err, []string{file})
+		}
+	}
+}
+
+func TestFilepathGlobEscapedBase(t *testing.T) {
+	if onWindows {
+		t.Skip("escaping is disabled on Windows")
+	}
+
+	dir := filepath.Join(t.TempDir(), "a[b]")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "f.txt")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := QuoteMeta(dir) + "/*.txt"
+	matches, err := FilepathGlob(pattern)
+	if err != nil || len(matches) != 1 || matches[0] != file {
+		t.Errorf("FilepathGlob(%#q) = %#v, %v - should be %#v", pattern, matches, err, []string{file})
+	}
+
+	var walked []string
+	err = FilepathGlobWalk(pattern, func(p string, d fs.DirEntry) error {
+		walked = append(walked, p)
+		return nil
+	})
+	if err != nil || len(walked) != 1 || walked[0] != file {
+		t.Errorf("FilepathGlobWalk(%#q) = %#v, %v - should be %#v", pattern, walked, err, []string{file})
+	}
+}
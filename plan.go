@@ -0,0 +1,276 @@
+package doublestar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GlobPlan describes how Glob() will search for files matching a pattern,
+// without touching a filesystem. See Plan().
+type GlobPlan struct {
+	Pattern string
+
+	// UsesWalk is true if Glob() hands the pattern off to GlobWalk(), which it
+	// does if the pattern has a `**` anywhere but the very end. The steps are
+	// the same either way.
+	UsesWalk bool
+
+	// Branches is the total number of alternatives that Glob() will try, each
+	// of which is run once for every path found by its Alternatives step's
+	// From step (if any).
+	Branches int
+
+	Root *PlanStep
+}
+
+// PlanOp is the operation a PlanStep performs.
+type PlanOp int
+
+const (
+	// PlanStat stats Path, which has no meta characters, to check if it exists.
+	PlanStat PlanOp = iota
+
+	// PlanIsDir stats Path to check if it is a directory. This happens when a
+	// pattern ends in a `/`.
+	PlanIsDir
+
+	// PlanReadDir reads the directory Path and matches each entry against
+	// Pattern.
+	PlanReadDir
+
+	// PlanDoubleStar reads the directory Path and all of its subdirectories,
+	// recursively, for a `**` at the end of a pattern.
+	PlanDoubleStar
+
+	// PlanAlternatives runs each of Children, one per alternative of the
+	// alternation in Pattern, and merges the results.
+	PlanAlternatives
+)
+
+var planOpNames = []string{
+	PlanStat:         "PlanStat",
+	PlanIsDir:        "PlanIsDir",
+	PlanReadDir:      "PlanReadDir",
+	PlanDoubleStar:   "PlanDoubleStar",
+	PlanAlternatives: "PlanAlternatives",
+}
+
+func (op PlanOp) String() string {
+	if op >= 0 && int(op) < len(planOpNames) {
+		return planOpNames[op]
+	}
+	return fmt.Sprintf("PlanOp(%d)", int(op))
+}
+
+// PlanStep is one step of a GlobPlan.
+type PlanStep struct {
+	Op PlanOp
+
+	// Glob is the part of the pattern that this step (including From) finds.
+	// For the children of an Alternatives step, it is one alternative, plus
+	// the rest of the pattern.
+	Glob string
+
+	// Path is the path that this step stats or reads. If From is not nil, Path
+	// is empty because the step runs once for each path that From finds
+	// instead. Inside of an Alternatives step whose From step isn't a Stat,
+	// paths are relative to each path From finds.
+	Path string
+
+	// Pattern is the pattern that ReadDir matches each entry against, or the
+	// alternation for Alternatives.
+	Pattern string
+
+	// DirsOnly is true if only directories will match, because the pattern
+	// continues after this step.
+	DirsOnly bool
+
+	From     *PlanStep
+	Children []*PlanStep
+}
+
+// Plan describes how Glob() would search for files matching the pattern,
+// without touching a filesystem, which is useful for debugging slow globs.
+// The plan is a tree of steps: which literal paths are stat'd, which
+// directories are read (and what their entries are matched against), where
+// recursion for `**` starts, and which alternatives are tried. Since Glob()
+// processes a pattern from the last path segment backward, a step that needs
+// a list of directories has a From step that finds them.
+//
+// GlobPlan.String() formats the plan as a tree. For example, the plan for
+// `src/*/**/*.go` is:
+//
+//   plan for `src/*/**/*.go` (using GlobWalk), 0 branches:
+//     readdir, match `*.go`, in each of:
+//       readdir recursively for `**` (directories only), in each of:
+//         readdir `src`, match `*` (directories only)
+//
+// The only possible returned error is ErrBadPattern, when pattern is
+// malformed.
+//
+func Plan(pattern string) (*GlobPlan, error) {
+	if !ValidatePattern(pattern) {
+		return nil, ErrBadPattern
+	}
+
+	p := &GlobPlan{
+		Pattern:  pattern,
+		UsesWalk: hasMidDoubleStar(pattern),
+		Root:     planGlob(pattern, true),
+	}
+	p.Branches = p.Root.countBranches()
+	return p, nil
+}
+
+// Plans doGlob() and doGlobWalk(), which work the same way.
+func planGlob(pattern string, firstSegment bool) *PlanStep {
+	patternStart := indexMeta(pattern)
+	if patternStart == -1 {
+		return &PlanStep{Op: PlanStat, Glob: pattern, Path: Unescape(pattern)}
+	}
+
+	glob := pattern
+	dir := "."
+	splitIdx := lastIndexSlashOrAlt(pattern)
+	if splitIdx != -1 {
+		if pattern[splitIdx] == '}' {
+			openingIdx := indexMatchedOpeningAlt(pattern[:splitIdx])
+			if openingIdx == -1 {
+				splitIdx = lastIndexSlash(pattern[:splitIdx])
+			} else {
+				return planAlts(pattern, openingIdx, splitIdx, firstSegment)
+			}
+		}
+
+		dir = pattern[:splitIdx]
+		pattern = pattern[splitIdx+1:]
+	}
+
+	if splitIdx <= patternStart {
+		return planDir(glob, dir, pattern, firstSegment, nil)
+	}
+	return planDir(glob, "", pattern, firstSegment, planGlob(dir, false))
+}
+
+// Plans globAlts() and globAltsWalk().
+func planAlts(pattern string, openingIdx, closingIdx int, firstSegment bool) *PlanStep {
+	step := &PlanStep{Op: PlanAlternatives, Glob: pattern, Pattern: pattern[openingIdx : closingIdx+1]}
+
+	prefix := ""
+	startIdx := 0
+	afterIdx := closingIdx + 1
+	splitIdx := lastIndexSlashOrAlt(pattern[:openingIdx])
+	if splitIdx != -1 && pattern[splitIdx] != '}' {
+		// the alts have a common prefix that is processed first; if it's a
+		// literal path, the alts can be planned with it
+		step.From = planGlob(pattern[:splitIdx], false)
+		if step.From.Op == PlanStat {
+			prefix = step.From.Path
+		}
+		startIdx = splitIdx + 1
+	}
+
+	patIdx := openingIdx + 1
+	for patIdx < closingIdx {
+		nextIdx := indexNextAlt(pattern[patIdx:closingIdx], true)
+		if nextIdx == -1 {
+			nextIdx = closingIdx
+		} else {
+			nextIdx += patIdx
+		}
+
+		alt := buildAlt(prefix, pattern, startIdx, openingIdx, patIdx, nextIdx, afterIdx)
+		step.Children = append(step.Children, planGlob(alt, firstSegment))
+		patIdx = nextIdx + 1
+	}
+	return step
+}
+
+// Plans globDir() and globDirWalk().
+func planDir(glob, dir, pattern string, canMatchFiles bool, from *PlanStep) *PlanStep {
+	step := &PlanStep{Glob: glob, Path: dir, DirsOnly: !canMatchFiles, From: from}
+	switch pattern {
+	case "":
+		step.Op = PlanIsDir
+		step.DirsOnly = false
+	case "**":
+		step.Op = PlanDoubleStar
+	default:
+		step.Op = PlanReadDir
+		step.Pattern = pattern
+	}
+	return step
+}
+
+func (s *PlanStep) countBranches() int {
+	branches := len(s.Children)
+	if s.From != nil {
+		branches += s.From.countBranches()
+	}
+	for _, c := range s.Children {
+		branches += c.countBranches()
+	}
+	return branches
+}
+
+// String formats the plan as a tree. See Plan() for an example.
+func (p *GlobPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "plan for %#q", p.Pattern)
+	if p.UsesWalk {
+		b.WriteString(" (using GlobWalk)")
+	}
+	if p.Branches == 1 {
+		b.WriteString(", 1 branch:\n")
+	} else {
+		fmt.Fprintf(&b, ", %d branches:\n", p.Branches)
+	}
+	p.Root.write(&b, 1)
+	return b.String()
+}
+
+func (s *PlanStep) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+
+	var path string
+	if s.From == nil {
+		path = fmt.Sprintf(" %#q", s.Path)
+	}
+	switch s.Op {
+	case PlanStat:
+		fmt.Fprintf(b, "stat%s", path)
+	case PlanIsDir:
+		fmt.Fprintf(b, "stat%s (must be a directory)", path)
+	case PlanReadDir:
+		fmt.Fprintf(b, "readdir%s, match %#q", path, s.Pattern)
+	case PlanDoubleStar:
+		fmt.Fprintf(b, "readdir%s recursively for `**`", path)
+	case PlanAlternatives:
+		if len(s.Children) == 1 {
+			fmt.Fprintf(b, "1 alternative of %#q", s.Pattern)
+		} else {
+			fmt.Fprintf(b, "%d alternatives of %#q", len(s.Children), s.Pattern)
+		}
+	}
+	if s.DirsOnly {
+		b.WriteString(" (directories only)")
+	}
+
+	switch {
+	case s.Op == PlanAlternatives:
+		b.WriteString(":\n")
+		if s.From != nil {
+			fmt.Fprintf(b, "%sin each of:\n", strings.Repeat("  ", depth+1))
+			s.From.write(b, depth+2)
+		}
+		for _, c := range s.Children {
+			fmt.Fprintf(b, "%salternative %#q:\n", strings.Repeat("  ", depth+1), c.Glob)
+			c.write(b, depth+2)
+		}
+	case s.From != nil:
+		b.WriteString(", in each of:\n")
+		s.From.write(b, depth+1)
+	default:
+		b.WriteByte('\n')
+	}
+}
@@ -0,0 +1,95 @@
+package doublestar
+
+import (
+	"testing"
+)
+
+type PlanTest struct {
+	pattern  string
+	expected string // expected GlobPlan.String()
+}
+
+var planTests = []PlanTest{
+	{"a/b/c", "plan for `a/b/c`, 0 branches:\n" +
+		"  stat `a/b/c`\n"},
+	{"a\\*b", "plan for `a\\*b`, 0 branches:\n" +
+		"  stat `a*b`\n"},
+	{"a/**", "plan for `a/**`, 0 branches:\n" +
+		"  readdir `a` recursively for `**`\n"},
+	{"a/*/", "plan for `a/*/`, 0 branches:\n" +
+		"  stat (must be a directory), in each of:\n" +
+		"    readdir `a`, match `*` (directories only)\n"},
+	{"src/*/**/*.go", "plan for `src/*/**/*.go` (using GlobWalk), 0 branches:\n" +
+		"  readdir, match `*.go`, in each of:\n" +
+		"    readdir recursively for `**` (directories only), in each of:\n" +
+		"      readdir `src`, match `*` (directories only)\n"},
+	{"src/{a,b*}/x", "plan for `src/{a,b*}/x`, 2 branches:\n" +
+		"  readdir, match `x`, in each of:\n" +
+		"    2 alternatives of `{a,b*}`:\n" +
+		"      in each of:\n" +
+		"        stat `src`\n" +
+		"      alternative `src/a`:\n" +
+		"        stat `src/a`\n" +
+		"      alternative `src/b*`:\n" +
+		"        readdir `src`, match `b*` (directories only)\n"},
+	{"*/{x,y}", "plan for `*/{x,y}`, 2 branches:\n" +
+		"  2 alternatives of `{x,y}`:\n" +
+		"    in each of:\n" +
+		"      readdir `.`, match `*` (directories only)\n" +
+		"    alternative `x`:\n" +
+		"      stat `x`\n" +
+		"    alternative `y`:\n" +
+		"      stat `y`\n"},
+	{"{a,b}/{c,d}", "plan for `{a,b}/{c,d}`, 4 branches:\n" +
+		"  2 alternatives of `{c,d}`:\n" +
+		"    in each of:\n" +
+		"      2 alternatives of `{a,b}`:\n" +
+		"        alternative `a`:\n" +
+		"          stat `a`\n" +
+		"        alternative `b`:\n" +
+		"          stat `b`\n" +
+		"    alternative `c`:\n" +
+		"      stat `c`\n" +
+		"    alternative `d`:\n" +
+		"      stat `d`\n"},
+	{"x{a,}", "plan for `x{a,}`, 1 branch:\n" +
+		"  1 alternative of `{a,}`:\n" +
+		"    alternative `xa`:\n" +
+		"      stat `xa`\n"},
+}
+
+func TestPlan(t *testing.T) {
+	for idx, tt := range planTests {
+		p, err := Plan(tt.pattern)
+		if err != nil {
+			t.Errorf("#%v. Plan(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+		if s := p.String(); s != tt.expected {
+			t.Errorf("#%v. Plan(%#q) =\n%v\nshould be\n%v", idx, tt.pattern, s, tt.expected)
+		}
+	}
+
+	if _, err := Plan("a["); err != ErrBadPattern {
+		t.Errorf("Plan(`a[`) = %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestPlanMatchTests(t *testing.T) {
+	for idx, tt := range matchTests {
+		p, err := Plan(tt.pattern)
+		if tt.expectedErr != nil {
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%v. Plan(%#q) returned error %v", idx, tt.pattern, err)
+			continue
+		}
+		if indexMeta(tt.pattern) == -1 && (p.Root.Op != PlanStat || p.Root.Path != Unescape(tt.pattern)) {
+			t.Errorf("#%v. Plan(%#q).Root = %+v - should stat the pattern", idx, tt.pattern, p.Root)
+		}
+		if p.Root.Glob != tt.pattern {
+			t.Errorf("#%v. Plan(%#q).Root.Glob = %#q - should be the pattern", idx, tt.pattern, p.Root.Glob)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package doublestar
+
+import (
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// GlobSnapshot records the paths that matched a set of patterns, and their
+// size, modification time, and mode, at some point in time. See Snapshot()
+// and Diff().
+//
+// A GlobSnapshot can be persisted between runs with encoding/json. The JSON
+// format is stable: fields may be added in the future, but existing fields
+// won't be renamed or change meaning, so a snapshot written by one version of
+// this package can be read by any later version.
+type GlobSnapshot struct {
+	// Patterns are the patterns that were passed to Snapshot().
+	Patterns []string `json:"patterns"`
+
+	// Entries are the paths that matched any of the patterns, sorted by path,
+	// without duplicates.
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+// SnapshotEntry is a path in a GlobSnapshot.
+type SnapshotEntry struct {
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	ModTime time.Time   `json:"modTime"`
+	Mode    fs.FileMode `json:"mode"`
+}
+
+// SnapshotDiff is the difference between two GlobSnapshots. See Diff().
+type SnapshotDiff struct {
+	// Added are the entries in the new snapshot whose paths aren't in the old
+	// one.
+	Added []SnapshotEntry
+
+	// Removed are the entries in the old snapshot whose paths aren't in the
+	// new one.
+	Removed []SnapshotEntry
+
+	// Modified are the entries in the new snapshot whose paths are also in the
+	// old one, but with a different size, modification time, or mode.
+	Modified []SnapshotEntry
+}
+
+// Snapshot globs each of `patterns` in `fsys` and records every match, along
+// with the size, modification time, and mode from fs.Stat(), in a
+// GlobSnapshot. Pass an earlier and a later snapshot to Diff() to find out
+// what changed in between. `opts` are passed to GlobWalk() for each pattern.
+// Symlinks are followed, so a change to the file a symlink points to shows up
+// as a change to the symlink, too. A match that can't be stat'd, such as a
+// file that was removed while globbing, or a broken symlink, is left out.
+// Modification times are recorded in UTC.
+//
+// Snapshot returns ErrBadPattern if any of the patterns is malformed, without
+// globbing any of them. Otherwise, it may only return an error if an option
+// that returns I/O errors was passed: with WithCollectIOErrors, the snapshot
+// is returned along with the errors, like Glob().
+//
+func Snapshot(fsys fs.FS, patterns []string, opts ...GlobOption) (*GlobSnapshot, error) {
+	for _, pattern := range patterns {
+		if !ValidatePattern(pattern) {
+			return nil, ErrBadPattern
+		}
+	}
+
+	seen := make(map[string]bool)
+	snap := &GlobSnapshot{Patterns: append([]string(nil), patterns...)}
+	var ioErrors joinedIOErrors
+	for _, pattern := range patterns {
+		err := GlobWalk(fsys, pattern, func(p string, d fs.DirEntry) error {
+			if seen[p] {
+				return nil
+			}
+			// for a symlink, d.Info() describes the link itself, so stat the match to
+			// describe the file it points to
+			info, err := fs.Stat(fsys, p)
+			if err != nil {
+				return nil
+			}
+
+			seen[p] = true
+			snap.Entries = append(snap.Entries, SnapshotEntry{
+				Path:    p,
+				Size:    info.Size(),
+				ModTime: info.ModTime().UTC(),
+				Mode:    info.Mode(),
+			})
+			return nil
+		}, opts...)
+		if err != nil {
+			joined, ok := err.(joinedIOErrors)
+			if !ok {
+				return nil, err
+			}
+			ioErrors = append(ioErrors, joined...)
+		}
+	}
+
+	sort.Slice(snap.Entries, func(i, j int) bool { return snap.Entries[i].Path < snap.Entries[j].Path })
+	if ioErrors != nil {
+		return snap, ioErrors
+	}
+	return snap, nil
+}
+
+// Diff returns the entries that were added, removed, or modified between the
+// snapshots `before` and `after`, each sorted by path. Paths are compared
+// without regard to the patterns that produced them. Either snapshot may be
+// nil, which is the same as an empty snapshot, so the first run of a build
+// tool can pass a nil `before` to treat every path as added.
+//
+func Diff(before, after *GlobSnapshot) *SnapshotDiff {
+	var oldEntries, newEntries []SnapshotEntry
+	if before != nil {
+		oldEntries = before.Entries
+	}
+	if after != nil {
+		newEntries = after.Entries
+	}
+
+	prev := make(map[string]SnapshotEntry, len(oldEntries))
+	for _, e := range oldEntries {
+		prev[e.Path] = e
+	}
+
+	diff := &SnapshotDiff{}
+	for _, e := range newEntries {
+		pe, ok := prev[e.Path]
+		if !ok {
+			diff.Added = append(diff.Added, e)
+			continue
+		}
+		delete(prev, e.Path)
+		if pe.Size != e.Size || !pe.ModTime.Equal(e.ModTime) || pe.Mode != e.Mode {
+			diff.Modified = append(diff.Modified, e)
+		}
+	}
+	for _, e := range oldEntries {
+		if _, ok := prev[e.Path]; ok {
+			diff.Removed = append(diff.Removed, e)
+			delete(prev, e.Path)
+		}
+	}
+
+	// the entries are already sorted, unless they were built by hand or
+	// decoded from JSON that was edited
+	for _, entries := range [][]SnapshotEntry{diff.Added, diff.Removed, diff.Modified} {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	}
+	return diff
+}
@@ -0,0 +1,120 @@
+package doublestar
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+func snapshotPaths(entries []SnapshotEntry) (paths []string) {
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	return
+}
+
+func TestSnapshot(t *testing.T) {
+	fsys := memfs.New().
+		File("go.mod", []byte("module x")).
+		File("src/a.go", []byte("a")).
+		File("src/b.go", []byte("b")).
+		File("src/c.txt", []byte("c"))
+
+	patterns := []string{"src/*.go", "**/*.go", "go.mod"}
+	before, err := Snapshot(fsys, patterns)
+	if err != nil {
+		t.Fatalf("Snapshot(%#v) has error %v", patterns, err)
+	}
+	expected := []string{"go.mod", "src/a.go", "src/b.go"}
+	if paths := snapshotPaths(before.Entries); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Snapshot(%#v) = %#v - should be %#v", patterns, paths, expected)
+	}
+
+	fsys.Remove("src/a.go").
+		File("src/b.go", []byte("bb")).
+		File("src/d.go", nil).
+		Touch("go.mod", time.Unix(1600000000, 0))
+	after, err := Snapshot(fsys, patterns)
+	if err != nil {
+		t.Fatalf("Snapshot(%#v) has error %v", patterns, err)
+	}
+
+	diff := Diff(before, after)
+	if added := snapshotPaths(diff.Added); !reflect.DeepEqual(added, []string{"src/d.go"}) {
+		t.Errorf("Diff().Added = %#v - should be [src/d.go]", added)
+	}
+	if removed := snapshotPaths(diff.Removed); !reflect.DeepEqual(removed, []string{"src/a.go"}) {
+		t.Errorf("Diff().Removed = %#v - should be [src/a.go]", removed)
+	}
+	if modified := snapshotPaths(diff.Modified); !reflect.DeepEqual(modified, []string{"go.mod", "src/b.go"}) {
+		t.Errorf("Diff().Modified = %#v - should be [go.mod src/b.go]", modified)
+	}
+	if diff.Modified[1].Size != 2 {
+		t.Errorf("Diff().Modified[1].Size = %d - should be the new size, 2", diff.Modified[1].Size)
+	}
+
+	if diff = Diff(nil, after); !reflect.DeepEqual(diff.Added, after.Entries) || diff.Removed != nil || diff.Modified != nil {
+		t.Errorf("Diff(nil, after) = %#v - should add every entry", diff)
+	}
+	if diff = Diff(after, nil); !reflect.DeepEqual(diff.Removed, after.Entries) || diff.Added != nil || diff.Modified != nil {
+		t.Errorf("Diff(after, nil) = %#v - should remove every entry", diff)
+	}
+
+	if _, err := Snapshot(fsys, []string{"src/*.go", "src/["}); err != ErrBadPattern {
+		t.Errorf("Snapshot(`src/[`) has error %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestSnapshotSymlink(t *testing.T) {
+	fsys := memfs.New().
+		File("src/a.go", []byte("a")).
+		Symlink("src/link.go", "a.go")
+
+	patterns := []string{"src/*.go"}
+	before, err := Snapshot(fsys, patterns)
+	if err != nil {
+		t.Fatalf("Snapshot(%#v) has error %v", patterns, err)
+	}
+	if len(before.Entries) != 2 || before.Entries[1].Path != "src/link.go" || !before.Entries[1].Mode.IsRegular() {
+		t.Fatalf("Snapshot(%#v) = %#v - should describe the file src/link.go points to", patterns, before.Entries)
+	}
+
+	fsys.File("src/a.go", []byte("aa"))
+	after, err := Snapshot(fsys, patterns)
+	if err != nil {
+		t.Fatalf("Snapshot(%#v) has error %v", patterns, err)
+	}
+	expected := []string{"src/a.go", "src/link.go"}
+	if modified := snapshotPaths(Diff(before, after).Modified); !reflect.DeepEqual(modified, expected) {
+		t.Errorf("Diff().Modified = %#v - should be %#v", modified, expected)
+	}
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	snap := &GlobSnapshot{
+		Patterns: []string{"*.go"},
+		Entries: []SnapshotEntry{
+			{Path: "a.go", Size: 1, ModTime: time.Unix(1600000000, 5).UTC(), Mode: 0644},
+		},
+	}
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatalf("json.Marshal() has error %v", err)
+	}
+	expected := `{"patterns":["*.go"],"entries":[{"path":"a.go","size":1,"modTime":"2020-09-13T12:26:40.000000005Z","mode":420}]}`
+	if string(data) != expected {
+		t.Errorf("json.Marshal() = %s - should be %s", data, expected)
+	}
+
+	var decoded GlobSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() has error %v", err)
+	}
+	if diff := Diff(snap, &decoded); diff.Added != nil || diff.Removed != nil || diff.Modified != nil {
+		t.Errorf("Diff() of a snapshot and its JSON round trip = %#v - should be empty", diff)
+	}
+}
@@ -0,0 +1,77 @@
+package doublestar
+
+import "errors"
+
+// ErrTooComplex is returned by Subsumes and Intersects when comparing the
+// patterns would take too long.
+var ErrTooComplex = errors.New("doublestar: patterns are too complex to compare")
+
+// Subsumes returns true if pattern `a` matches every name that pattern `b`
+// matches. For example, `src/**` subsumes `src/*.go`, but `src/*` does not
+// subsume `src/**/*.go`. This is useful for finding rules that can never take
+// effect because an earlier, broader rule always matches first.
+//
+// Subsumes compares the patterns exactly as Match() would run them, corner
+// cases and all, without enumerating names. Both patterns must use `/` as the
+// path separator. Subsumes returns ErrBadPattern if either pattern is
+// malformed.
+//
+// Usually, comparing patterns is fast, but, in the worst case, the time and
+// memory it takes grow exponentially with the length of the patterns: for
+// example, with a `*` followed by many `?`, as in `*a????????????????`,
+// Subsumes has to consider every combination of the `?` matching an `a` or
+// not. So, Subsumes gives up and returns ErrTooComplex after a fixed amount
+// of work, which takes at most a few hundred milliseconds. This makes it safe
+// to use with patterns from untrusted sources.
+//
+func Subsumes(a, b string) (bool, error) {
+	pa, pb, err := newAutomata(a, b)
+	if err != nil {
+		return false, err
+	}
+
+	_, found, err := findName(pa, pb, func(aMatches, bMatches bool) bool {
+		return bMatches && !aMatches
+	})
+	if err != nil {
+		return false, err
+	}
+	return !found, nil
+}
+
+// Intersects returns true if there is at least one name that both pattern `a`
+// and pattern `b` match. For example, `*.go` and `main.*` intersect (both
+// match `main.go`), but `*.go` and `*.txt` do not.
+//
+// Like Subsumes, Intersects compares the patterns exactly as Match() would
+// run them, and both patterns must use `/` as the path separator. Intersects
+// returns ErrBadPattern if either pattern is malformed. It has the same worst
+// case as Subsumes: if comparing the patterns would take too long, it returns
+// ErrTooComplex.
+//
+func Intersects(a, b string) (bool, error) {
+	pa, pb, err := newAutomata(a, b)
+	if err != nil {
+		return false, err
+	}
+
+	_, found, err := findName(pa, pb, func(aMatches, bMatches bool) bool {
+		return aMatches && bMatches
+	})
+	if err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+func newAutomata(a, b string) (*automaton, *automaton, error) {
+	pa, err := newAutomaton(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	pb, err := newAutomaton(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pa, pb, nil
+}
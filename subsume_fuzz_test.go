@@ -0,0 +1,56 @@
+//go:build go1.18
+// +build go1.18
+
+package doublestar
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+func FuzzSubsumes(f *testing.F) {
+	for _, tt := range subsumeTests {
+		f.Add(tt.a, tt.b, "a/b")
+	}
+	for _, tt := range matchTests {
+		f.Add(tt.pattern, "**", tt.testPath)
+	}
+
+	f.Fuzz(func(t *testing.T, a, b, name string) {
+		if _, err := syntax.Parse(a); err != nil {
+			return
+		}
+		if _, err := syntax.Parse(b); err != nil {
+			return
+		}
+		if strings.Count(a+b, "{") > 8 {
+			// Match() takes exponential time on patterns with many alternations
+			return
+		}
+
+		aMatches, _ := Match(a, name)
+		bMatches, _ := Match(b, name)
+		if subsumes, err := Subsumes(a, b); err == ErrTooComplex {
+			return
+		} else if err != nil {
+			t.Fatalf("Subsumes(%#q, %#q) returned error %v", a, b, err)
+		} else if subsumes && bMatches && !aMatches {
+			t.Fatalf("Subsumes(%#q, %#q) = true, but only %#q matches %#q", a, b, b, name)
+		}
+
+		if intersects, err := Intersects(a, b); err == ErrTooComplex {
+			return
+		} else if err != nil {
+			t.Fatalf("Intersects(%#q, %#q) returned error %v", a, b, err)
+		} else if !intersects && aMatches && bMatches {
+			t.Fatalf("Intersects(%#q, %#q) = false, but both match %#q", a, b, name)
+		}
+
+		// a pattern intersects a quoted name exactly when it matches the name
+		if intersects, err := Intersects(a, QuoteMeta(name)); err == nil && intersects != aMatches {
+			t.Fatalf("Intersects(%#q, %#q) = %v, but Match(%#q, %#q) = %v", a, QuoteMeta(name), intersects, a, name, aMatches)
+		}
+	})
+}
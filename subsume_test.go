@@ -0,0 +1,143 @@
+package doublestar
+
+import (
+	"strings"
+	"testing"
+)
+
+type SubsumeTest struct {
+	a, b       string // patterns to compare
+	subsumes   bool   // true if a matches everything b matches
+	intersects bool   // true if a and b match at least one name in common
+}
+
+var subsumeTests = []SubsumeTest{
+	{"", "", true, true},
+	{"a", "a", true, true},
+	{"a", "b", false, false},
+	{"*", "a", true, true},
+	{"a", "*", false, true},
+	{"*", "a/b", false, false},
+	{"**", "a/b", true, true},
+	{"**", "*", true, true},
+	{"src/**", "src/*.go", true, true},
+	{"src/*", "src/**/*.go", false, true},
+	{"src/**/*.go", "src/*.go", true, true},
+	{"src/**.go", "src/a/b.go", false, false},
+	{"*.go", "main.*", false, true},
+	{"*.go", "*.txt", false, false},
+	{"{a,b}/*", "a/x", true, true},
+	{"{a,b}/*", "{b,a}/*", true, true},
+	{"{a,b}/*", "c/*", false, false},
+	{"[a-c]", "b", true, true},
+	{"[a-c]", "[b-d]", false, true},
+	{"[^a]", "a", false, false},
+	{"[^a]", "/", true, true},
+	{"?", "/", false, false},
+	{"[z-a]", "z", true, true},
+	{"[z-a]", "y", false, false},
+	{"a/**", "a", true, true},
+	{"a/**/**", "a", false, false},
+	{"**/**", "", false, false},
+	{"**/**", "**", false, true},
+	{"**", "**/**", true, true},
+	{"a/***", "a/", false, false},
+	{"a/*", "a/", true, true},
+	{"a{**/b}", "ax/b", true, true},
+	{"a**/b", "ax/b", true, true},
+	{"a**/b", "ax/y/b", false, false},
+	{"a{**/b}", "ax/y/b", true, true},
+}
+
+func TestSubsumes(t *testing.T) {
+	for idx, tt := range subsumeTests {
+		subsumes, err := Subsumes(tt.a, tt.b)
+		if err != nil || subsumes != tt.subsumes {
+			t.Errorf("#%v. Subsumes(%#q, %#q) = %v, %v - should be %v", idx, tt.a, tt.b, subsumes, err, tt.subsumes)
+		}
+
+		intersects, err := Intersects(tt.a, tt.b)
+		if err != nil || intersects != tt.intersects {
+			t.Errorf("#%v. Intersects(%#q, %#q) = %v, %v - should be %v", idx, tt.a, tt.b, intersects, err, tt.intersects)
+		}
+
+		if intersects, _ := Intersects(tt.b, tt.a); intersects != tt.intersects {
+			t.Errorf("#%v. Intersects(%#q, %#q) = %v - should be %v", idx, tt.b, tt.a, intersects, tt.intersects)
+		}
+	}
+}
+
+func TestSubsumesBadPattern(t *testing.T) {
+	if _, err := Subsumes("a[", "a"); err != ErrBadPattern {
+		t.Errorf("Subsumes(`a[`, `a`) = %v - should be ErrBadPattern", err)
+	}
+	if _, err := Intersects("a", "{a"); err != ErrBadPattern {
+		t.Errorf("Intersects(`a`, `{a`) = %v - should be ErrBadPattern", err)
+	}
+}
+
+func TestSubsumesTooComplex(t *testing.T) {
+	// `*a` followed by n `?` takes 2^(n+1) sets of states to compare
+	p := "*a" + strings.Repeat("?", 16)
+	if _, err := Subsumes(p, p); err != ErrTooComplex {
+		t.Errorf("Subsumes(%#q, %#q) = %v - should be ErrTooComplex", p, p, err)
+	}
+	if _, err := Intersects(p, p+"/x"); err != ErrTooComplex {
+		t.Errorf("Intersects(%#q, %#q) = %v - should be ErrTooComplex", p, p+"/x", err)
+	}
+}
+
+func TestSubsumesMatchTests(t *testing.T) {
+	// A pattern intersects a quoted name exactly when it matches the name.
+	for idx, tt := range matchTests {
+		if tt.expectedErr != nil {
+			continue
+		}
+
+		intersects, err := Intersects(tt.pattern, QuoteMeta(tt.testPath))
+		if err != nil || intersects != tt.shouldMatch {
+			t.Errorf("#%v. Intersects(%#q, %#q) = %v, %v - should be %v", idx, tt.pattern, QuoteMeta(tt.testPath), intersects, err, tt.shouldMatch)
+		}
+
+		subsumes, err := Subsumes(tt.pattern, QuoteMeta(tt.testPath))
+		if err != nil || subsumes != tt.shouldMatch {
+			t.Errorf("#%v. Subsumes(%#q, %#q) = %v, %v - should be %v", idx, tt.pattern, QuoteMeta(tt.testPath), subsumes, err, tt.shouldMatch)
+		}
+	}
+}
+
+func TestSubsumesWitness(t *testing.T) {
+	// Whenever Subsumes or Intersects decides based on an example name, the
+	// example must agree with Match.
+	var patterns, others []string
+	for _, tt := range matchTests {
+		if tt.expectedErr == nil {
+			patterns = append(patterns, tt.pattern)
+		}
+	}
+	for _, tt := range subsumeTests {
+		others = append(others, tt.a, tt.b)
+	}
+
+	for _, a := range patterns {
+		for _, b := range others {
+			pa, pb, _ := newAutomata(a, b)
+			if name, ok, _ := findName(pa, pb, func(aMatches, bMatches bool) bool { return bMatches && !aMatches }); ok {
+				aMatches, _ := Match(a, name)
+				bMatches, _ := Match(b, name)
+				if aMatches || !bMatches {
+					t.Errorf("%#q does not subsume %#q because of %#q, but Match() = %v, %v", a, b, name, aMatches, bMatches)
+				}
+			}
+
+			pa, pb, _ = newAutomata(a, b)
+			if name, ok, _ := findName(pa, pb, func(aMatches, bMatches bool) bool { return aMatches && bMatches }); ok {
+				aMatches, _ := Match(a, name)
+				bMatches, _ := Match(b, name)
+				if !aMatches || !bMatches {
+					t.Errorf("%#q intersects %#q because of %#q, but Match() = %v, %v", a, b, name, aMatches, bMatches)
+				}
+			}
+		}
+	}
+}
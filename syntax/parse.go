@@ -0,0 +1,278 @@
+package syntax
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Error is returned by Parse when a pattern is malformed. It describes what
+// is wrong and where. errors.Is(err, ErrBadPattern) will return true for an
+// *Error.
+type Error struct {
+	Pattern string // the pattern that failed to parse
+	Pos     int    // the byte offset in Pattern where the problem was found
+	Msg     string // a description of the problem
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("syntax error in pattern %q at offset %d: %s", e.Pattern, e.Pos, e.Msg)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrBadPattern
+}
+
+// Parse parses a pattern into a syntax tree. The returned node is always a
+// Sequence. Parse accepts the same patterns as doublestar.ValidatePattern(),
+// except for alternations with a character class that contains one of the
+// alternation's `,`, `{`, or `}`, such as `{[,]}`: like doublestar's matcher,
+// Parse finds the alternatives before it looks at classes, so the class is
+// not terminated. Match() returns ErrBadPattern for these patterns. If the
+// pattern is malformed, the returned error is an *Error.
+func Parse(pattern string) (*Node, error) {
+	p := &parser{pattern: pattern, end: len(pattern)}
+	n, err := p.parseSequence(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.pattern) {
+		// parseSequence only stops early at a `}` without a matching `{`
+		return nil, p.errorf(p.pos, "unmatched `}`")
+	}
+	return n, nil
+}
+
+type parser struct {
+	pattern string
+	pos     int
+	end     int // parsing stops here: the end of the current alternative
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &Error{Pattern: p.pattern, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Parses a Sequence. `depth` is the number of Alternations we're currently
+// in: if it's greater than zero, the sequence ends at the end of the current
+// alternative. Otherwise, it ends at the end of the pattern, or an unmatched
+// `}`.
+func (p *parser) parseSequence(depth int) (*Node, error) {
+	seq := &Node{Kind: Sequence, Pos: p.pos}
+	for p.pos < p.end {
+		start := p.pos
+		switch p.pattern[p.pos] {
+		case '*':
+			if p.pos++; p.pos < p.end && p.pattern[p.pos] == '*' {
+				p.pos++
+				seq.Children = append(seq.Children, &Node{Kind: DoubleStar, Pos: start, End: p.pos})
+			} else {
+				seq.Children = append(seq.Children, &Node{Kind: Star, Pos: start, End: p.pos})
+			}
+
+		case '?':
+			p.pos++
+			seq.Children = append(seq.Children, &Node{Kind: Any, Pos: start, End: p.pos})
+
+		case '/':
+			p.pos++
+			seq.Children = append(seq.Children, &Node{Kind: Separator, Pos: start, End: p.pos})
+
+		case '[':
+			n, err := p.parseClass()
+			if err != nil {
+				return nil, err
+			}
+			seq.Children = append(seq.Children, n)
+
+		case '{':
+			n, err := p.parseAlternation(depth)
+			if err != nil {
+				return nil, err
+			}
+			seq.Children = append(seq.Children, n)
+
+		case '}':
+			seq.End = p.pos
+			return seq, nil
+
+		case ',':
+			if depth > 0 {
+				seq.End = p.pos
+				return seq, nil
+			}
+			// outside of an alternation, a `,` is just a literal
+			fallthrough
+
+		default:
+			if err := p.parseLiteral(seq, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	seq.End = p.pos
+	return seq, nil
+}
+
+// Parses a Literal, appending it to `seq`. If the last node in `seq` is a
+// Literal, it is extended instead.
+func (p *parser) parseLiteral(seq *Node, depth int) error {
+	start := p.pos
+	var text []byte
+	for p.pos < p.end {
+		c := p.pattern[p.pos]
+		if c == '\\' {
+			if p.pos+1 >= p.end {
+				return p.errorf(p.pos, "unexpected end of pattern after `\\`")
+			}
+			text = append(text, p.pattern[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if c == '*' || c == '?' || c == '/' || c == '[' || c == '{' || c == '}' || (c == ',' && depth > 0) {
+			break
+		}
+		text = append(text, c)
+		p.pos++
+	}
+
+	if l := len(seq.Children); l > 0 && seq.Children[l-1].Kind == Literal {
+		last := seq.Children[l-1]
+		last.Text += string(text)
+		last.End = p.pos
+		last.raw = p.pattern[last.Pos:last.End]
+		return nil
+	}
+
+	seq.Children = append(seq.Children, &Node{
+		Kind: Literal,
+		Pos:  start,
+		End:  p.pos,
+		Text: string(text),
+		raw:  p.pattern[start:p.pos],
+	})
+	return nil
+}
+
+// Parses a Class - assumes p.pos is at the opening `[`. The rules here follow
+// doublestar's matcher exactly: a `-` only forms a range if it follows a
+// single character (not another range) and is not followed by the closing
+// `]`.
+func (p *parser) parseClass() (*Node, error) {
+	start := p.pos
+	n := &Node{Kind: Class, Pos: start}
+	if p.pos++; p.pos >= p.end {
+		return nil, p.errorf(start, "character class not terminated")
+	}
+	if c := p.pattern[p.pos]; c == '^' || c == '!' {
+		n.Negated = true
+		p.pos++
+	}
+	if p.pos >= p.end {
+		return nil, p.errorf(start, "character class not terminated")
+	}
+	if p.pattern[p.pos] == ']' {
+		return nil, p.errorf(start, "empty character class")
+	}
+
+	for p.pos < p.end && p.pattern[p.pos] != ']' {
+		lo, ok := p.nextClassRune()
+		if !ok {
+			return nil, p.errorf(start, "character class not terminated")
+		}
+
+		hi := lo
+		if p.pos+1 < p.end && p.pattern[p.pos] == '-' && p.pattern[p.pos+1] != ']' {
+			p.pos++
+			if hi, ok = p.nextClassRune(); !ok {
+				return nil, p.errorf(start, "character class not terminated")
+			}
+		}
+		n.Ranges = append(n.Ranges, Range{lo, hi})
+	}
+
+	if p.pos >= p.end {
+		return nil, p.errorf(start, "character class not terminated")
+	}
+
+	p.pos++
+	n.End = p.pos
+	n.raw = p.pattern[start:p.pos]
+	return n, nil
+}
+
+// Returns the next (possibly escaped) rune in a class. Returns false if the
+// pattern ended unexpectedly.
+func (p *parser) nextClassRune() (rune, bool) {
+	if p.pattern[p.pos] == '\\' {
+		if p.pos++; p.pos >= p.end {
+			return 0, false
+		}
+	}
+	r, size := utf8.DecodeRuneInString(p.pattern[p.pos:])
+	p.pos += size
+	return r, true
+}
+
+// Parses an Alternation - assumes p.pos is at the opening `{`. Like
+// doublestar's matcher, the alternatives are found by counting braces and
+// ignoring character classes, and then each one is parsed on its own. So, a
+// class can't contain the alternation's `,`, `{`, or `}`.
+func (p *parser) parseAlternation(depth int) (*Node, error) {
+	n := &Node{Kind: Alternation, Pos: p.pos}
+	closing := p.indexAltEnd(p.pos+1, p.end, false)
+	if closing < 0 {
+		return nil, p.errorf(p.end, "alternation not terminated")
+	}
+
+	end := p.end
+	defer func() { p.end = end }()
+	for {
+		// skip the opening `{` or `,`
+		p.pos++
+		if p.end = p.indexAltEnd(p.pos, closing, true); p.end < 0 {
+			p.end = closing
+		}
+		alt, err := p.parseSequence(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < p.end {
+			// parseSequence stopped at a `,` or `}` whose `{` was in a class
+			return nil, p.errorf(p.pos, "unexpected `%c` in alternation", p.pattern[p.pos])
+		}
+		n.Children = append(n.Children, alt)
+		if p.pos == closing {
+			p.pos++
+			n.End = p.pos
+			return n, nil
+		}
+	}
+}
+
+// Returns the index of the `}` that closes the alternation that `start` is
+// in, or, if `comma` is true, the index of the next `,` that separates its
+// alternatives. Returns -1 if there is no such index before `limit`. This
+// mirrors indexMatchedClosingAlt() and indexNextAlt() in doublestar's
+// matcher.
+func (p *parser) indexAltEnd(start, limit int, comma bool) int {
+	alts := 1
+	for i := start; i < limit; i++ {
+		switch p.pattern[i] {
+		case '\\':
+			// skip the next byte
+			i++
+		case '{':
+			alts++
+		case '}':
+			if alts--; alts == 0 && !comma {
+				return i
+			}
+		case ',':
+			if alts == 1 && comma {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,230 @@
+package syntax_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/bmatcuk/doublestar/v4/syntax"
+)
+
+var parseTests = []string{
+	"",
+	"abc",
+	"*",
+	"/*",
+	"a*/b",
+	"a*b?c*x",
+	"ab[c]",
+	"ab[b-d]",
+	"ab[^b-d]",
+	"a[!a]b",
+	"[a-ζ]*",
+	"[\\]a]",
+	"[\\-x]",
+	"[x-]",
+	"[-x]",
+	"[a-b-d]",
+	"[z-a]",
+	"a\\*b",
+	"a/\\[*\\]",
+	"\\a\\b",
+	"a\\/b",
+	"**",
+	"a/**",
+	"**/c",
+	"a/**/b",
+	"abc**",
+	"a**b",
+	"***",
+	"ab{c,d}",
+	"ab{c,d,*}",
+	"a{,bc}",
+	"{}",
+	"{a/{b,c},abc}",
+	"e/{\\*,\\?}",
+	"a,b",
+	"a]b",
+	"**/【*",
+	"\\",
+	"[",
+	"[^",
+	"[]a]",
+	"[^bc",
+	"a[",
+	"[\\",
+	"{",
+	"}",
+	"a{b",
+	"a}b",
+	"{a,b}}",
+	"ab{c,d}[",
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	for idx, pattern := range parseTests {
+		n, err := syntax.Parse(pattern)
+		valid := doublestar.ValidatePattern(pattern)
+		if valid != (err == nil) {
+			t.Errorf("#%v. Parse(%#q) has error %v, but ValidatePattern = %v", idx, pattern, err, valid)
+			continue
+		}
+		if err != nil {
+			var synErr *syntax.Error
+			if !errors.As(err, &synErr) || !errors.Is(err, syntax.ErrBadPattern) {
+				t.Errorf("#%v. Parse(%#q) has error %#v, which is not an *Error", idx, pattern, err)
+			}
+			continue
+		}
+		if n.Kind != syntax.Sequence || n.Pos != 0 || n.End != len(pattern) {
+			t.Errorf("#%v. Parse(%#q) = %v [%v:%v] - should be a Sequence spanning the pattern", idx, pattern, n.Kind, n.Pos, n.End)
+		}
+		if s := n.String(); s != pattern {
+			t.Errorf("#%v. Parse(%#q).String() = %#q - should round-trip", idx, pattern, s)
+		}
+
+		syntax.Walk(n, func(c *syntax.Node) bool {
+			if c.Pos < 0 || c.End > len(pattern) || c.Pos > c.End {
+				t.Errorf("#%v. Parse(%#q) has a %v with bad positions [%v:%v]", idx, pattern, c.Kind, c.Pos, c.End)
+			} else if c.Kind != syntax.Sequence && c.String() != pattern[c.Pos:c.End] {
+				t.Errorf("#%v. Parse(%#q) has a %v %#q, but source is %#q", idx, pattern, c.Kind, c.String(), pattern[c.Pos:c.End])
+			}
+			return true
+		})
+	}
+}
+
+// Patterns that ValidatePattern accepts, but Match rejects, because a class
+// contains an alternation's `,`, `{`, or `}`.
+var classInAlternationTests = []string{
+	"{[,]}",
+	"{a[,]b}",
+	"{[}]}",
+	"{[0{X]}",
+	"{[{]a}",
+	"{[a{]}x}",
+	"{[{],x}}",
+}
+
+func TestParseAgreesWithMatch(t *testing.T) {
+	patterns := append([]string{"{[b]c,d}", "{[{}]}", "{[{,}]}", "{a,[b]}", "[{]", "[,]", "{a}[}]"}, parseTests...)
+	patterns = append(patterns, classInAlternationTests...)
+	for idx, pattern := range patterns {
+		_, err := syntax.Parse(pattern)
+		var matchErr error
+		for _, name := range []string{"", "a", ",", "{", "}", "a/b"} {
+			if _, matchErr = doublestar.Match(pattern, name); matchErr != nil {
+				break
+			}
+		}
+		if (err != nil) != (matchErr == doublestar.ErrBadPattern) {
+			t.Errorf("#%v. Parse(%#q) has error %v, but Match has error %v", idx, pattern, err, matchErr)
+		}
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	n, err := syntax.Parse("a\\*/**/[^b-d_]{x,*}?")
+	if err != nil {
+		t.Fatalf("Parse has error %v, but should not", err)
+	}
+
+	kinds := []syntax.Kind{
+		syntax.Literal,
+		syntax.Separator,
+		syntax.DoubleStar,
+		syntax.Separator,
+		syntax.Class,
+		syntax.Alternation,
+		syntax.Any,
+	}
+	if len(n.Children) != len(kinds) {
+		t.Fatalf("Parse returned %v children - should be %v", len(n.Children), len(kinds))
+	}
+	for i, k := range kinds {
+		if n.Children[i].Kind != k {
+			t.Errorf("child %v is a %v - should be a %v", i, n.Children[i].Kind, k)
+		}
+	}
+
+	if lit := n.Children[0]; lit.Text != "a*" || lit.Pos != 0 || lit.End != 3 {
+		t.Errorf("literal = %#q [%v:%v] - should be `a*` [0:3]", lit.Text, lit.Pos, lit.End)
+	}
+
+	class := n.Children[4]
+	if !class.Negated || len(class.Ranges) != 2 || class.Ranges[0] != (syntax.Range{Lo: 'b', Hi: 'd'}) || class.Ranges[1] != (syntax.Range{Lo: '_', Hi: '_'}) {
+		t.Errorf("class = %#v - should be a negated class of b-d and _", class)
+	}
+
+	alt := n.Children[5]
+	if len(alt.Children) != 2 || alt.Children[0].String() != "x" || alt.Children[1].Children[0].Kind != syntax.Star {
+		t.Errorf("alternation = %v - should have alternatives `x` and `*`", alt)
+	}
+}
+
+func TestStringModified(t *testing.T) {
+	n, err := syntax.Parse("a\\b/[!x]")
+	if err != nil {
+		t.Fatalf("Parse has error %v, but should not", err)
+	}
+
+	n.Children[0].Text = "a*"
+	n.Children[2].Ranges = append(n.Children[2].Ranges, syntax.Range{Lo: ']', Hi: ']'})
+	if s := n.String(); s != "a\\*/[!x\\]]" && s != "a\\*/[^x\\]]" {
+		t.Errorf("String() = %#q - should be `a\\*/[^x\\]]`", s)
+	}
+
+	n = &syntax.Node{Kind: syntax.Sequence, Children: []*syntax.Node{
+		{Kind: syntax.Literal, Text: "{a,b}]"},
+		{Kind: syntax.Class, Ranges: []syntax.Range{{Lo: '^', Hi: '^'}, {Lo: '-', Hi: '-'}}},
+		{Kind: syntax.Alternation, Children: []*syntax.Node{
+			{Kind: syntax.Sequence, Children: []*syntax.Node{{Kind: syntax.Literal, Text: "c,d"}}},
+		}},
+	}}
+	s := n.String()
+	if s != "\\{a,b\\}][\\^\\-]{c\\,d}" {
+		t.Errorf("String() = %#q - should be `\\{a,b\\}][\\^\\-]{c\\,d}`", s)
+	}
+	if ok, _ := doublestar.Match(s, "{a,b}]^c,d"); !ok {
+		t.Errorf("Match(%#q, `{a,b}]^c,d`) = false - should be true", s)
+	}
+
+	n = &syntax.Node{Kind: syntax.Sequence, Children: []*syntax.Node{
+		{Kind: syntax.Alternation, Children: []*syntax.Node{
+			{Kind: syntax.Sequence},
+			{Kind: syntax.Sequence, Children: []*syntax.Node{
+				{Kind: syntax.Class, Ranges: []syntax.Range{{Lo: ',', Hi: ','}, {Lo: '{', Hi: '{'}, {Lo: '}', Hi: '}'}}},
+			}},
+		}},
+	}}
+	s = n.String()
+	if s != "{,[\\,\\{\\}]}" {
+		t.Errorf("String() = %#q - should be `{,[\\,\\{\\}]}`", s)
+	}
+	for _, name := range []string{"", ",", "{", "}"} {
+		if ok, _ := doublestar.Match(s, name); !ok {
+			t.Errorf("Match(%#q, %#q) = false - should be true", s, name)
+		}
+	}
+}
+
+func TestParseErrorPos(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pos     int
+	}{
+		{"ab\\", 2},
+		{"ab[cd", 2},
+		{"a{b,c", 5},
+		{"a}b", 1},
+		{"{a[,]b}", 2},
+		{"{[a{]}x}", 5},
+	}
+	for idx, tt := range tests {
+		_, err := syntax.Parse(tt.pattern)
+		var synErr *syntax.Error
+		if !errors.As(err, &synErr) || synErr.Pos != tt.pos {
+			t.Errorf("#%v. Parse(%#q) has error %v - should be at offset %v", idx, tt.pattern, err, tt.pos)
+		}
+	}
+}
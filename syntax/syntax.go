@@ -0,0 +1,252 @@
+// Package syntax parses doublestar patterns into syntax trees.
+//
+// The grammar is the same one used by doublestar.Match() and doublestar.Glob()
+// (see the documentation for doublestar.Match() for details). Most users of
+// doublestar will not need this package; it is meant for tooling, such as
+// linters, editors, or converters, that needs to inspect or rewrite patterns.
+//
+// Patterns are parsed assuming `/` is the path separator.
+package syntax
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrBadPattern indicates a pattern was malformed. It is the same value as
+// doublestar.ErrBadPattern.
+var ErrBadPattern = path.ErrBadPattern
+
+// Kind is the type of a Node.
+type Kind int
+
+const (
+	// Sequence is a sequence of nodes, stored in Children. The root node
+	// returned from Parse is always a Sequence, as is each alternative of an
+	// Alternation.
+	Sequence Kind = iota
+
+	// Literal matches the string in Text exactly.
+	Literal
+
+	// Star is a `*`, which matches any sequence of non-separator characters.
+	Star
+
+	// DoubleStar is a `**`. If it is the only thing in a path segment (for
+	// example, `/**/`), it matches zero or more directories. Otherwise, it
+	// behaves like a Star.
+	DoubleStar
+
+	// Any is a `?`, which matches any single non-separator character.
+	Any
+
+	// Class is a character class (`[...]`), which matches any single
+	// non-separator character in Ranges, or not in Ranges if Negated is true.
+	Class
+
+	// Alternation is a list of alternatives (`{...}`). Each alternative is a
+	// Sequence stored in Children.
+	Alternation
+
+	// Separator is a path separator (`/`).
+	Separator
+)
+
+var kindNames = []string{
+	Sequence:    "Sequence",
+	Literal:     "Literal",
+	Star:        "Star",
+	DoubleStar:  "DoubleStar",
+	Any:         "Any",
+	Class:       "Class",
+	Alternation: "Alternation",
+	Separator:   "Separator",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Range is an inclusive range of runes in a character class. A single
+// character, such as `a` in `[abc]`, is represented by a Range where Lo and Hi
+// are equal.
+type Range struct {
+	Lo, Hi rune
+}
+
+// Node is a node in a pattern's syntax tree.
+type Node struct {
+	Kind Kind
+
+	// Pos and End are the byte offsets of the node in the source pattern, such
+	// that pattern[Pos:End] is the source of the node. They are only meaningful
+	// for nodes returned from Parse.
+	Pos, End int
+
+	// Text is the (unescaped) text of a Literal.
+	Text string
+
+	// Negated is true if a Class is negated (ie, `[^...]` or `[!...]`).
+	Negated bool
+
+	// Ranges are the ranges of a Class, in source order.
+	Ranges []Range
+
+	// Children are the child nodes of a Sequence or an Alternation.
+	Children []*Node
+
+	// raw is the source of a Literal or Class, as it appeared in the pattern.
+	// It is used by String() to reproduce the original pattern exactly, so long
+	// as the node has not been modified.
+	raw string
+}
+
+// String returns the pattern represented by the syntax tree rooted at `n`. If
+// `n` was returned from Parse and has not been modified, the result is the
+// same as the pattern that was parsed. Otherwise, the result is a pattern
+// that parses into an equivalent syntax tree.
+func (n *Node) String() string {
+	var b strings.Builder
+	n.writeTo(&b, false)
+	return b.String()
+}
+
+// Writes the pattern for `n` to `b`. `inAlt` is true if `n` is inside of an
+// Alternation, in which case commas (and braces, in classes) need to be
+// escaped.
+func (n *Node) writeTo(b *strings.Builder, inAlt bool) {
+	switch n.Kind {
+	case Sequence:
+		for _, c := range n.Children {
+			c.writeTo(b, inAlt)
+		}
+
+	case Literal:
+		if n.raw != "" && unescape(n.raw) == n.Text {
+			b.WriteString(n.raw)
+		} else {
+			writeQuotedLiteral(b, n.Text, inAlt)
+		}
+
+	case Star:
+		b.WriteByte('*')
+
+	case DoubleStar:
+		b.WriteString("**")
+
+	case Any:
+		b.WriteByte('?')
+
+	case Class:
+		if n.raw != "" && n.rawClassMatches() {
+			b.WriteString(n.raw)
+		} else {
+			writeClass(b, n.Negated, n.Ranges, inAlt)
+		}
+
+	case Alternation:
+		b.WriteByte('{')
+		for i, c := range n.Children {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			c.writeTo(b, true)
+		}
+		b.WriteByte('}')
+
+	case Separator:
+		b.WriteByte('/')
+	}
+}
+
+// Returns true if n.raw still represents the Class described by n.Negated and
+// n.Ranges.
+func (n *Node) rawClassMatches() bool {
+	p := &parser{pattern: n.raw, end: len(n.raw)}
+	c, err := p.parseClass()
+	if err != nil || p.pos != len(n.raw) || c.Negated != n.Negated || len(c.Ranges) != len(n.Ranges) {
+		return false
+	}
+	for i := range c.Ranges {
+		if c.Ranges[i] != n.Ranges[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes a literal, escaping any characters that would otherwise have a
+// special meaning. Commas only have a special meaning inside of an
+// Alternation. Slashes are escaped so that they won't be parsed as a
+// Separator.
+func writeQuotedLiteral(b *strings.Builder, s string, inAlt bool) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '*', '?', '[', '{', '}', '/':
+			b.WriteByte('\\')
+		case ',':
+			if inAlt {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(s[i])
+	}
+}
+
+// Writes a character class, escaping any characters that would otherwise have
+// a special meaning. Inside of an Alternation, doublestar's matcher finds the
+// alternatives before it looks at classes, so commas and braces need to be
+// escaped, too.
+func writeClass(b *strings.Builder, negated bool, ranges []Range, inAlt bool) {
+	b.WriteByte('[')
+	if negated {
+		b.WriteByte('^')
+	}
+	for i, r := range ranges {
+		writeClassRune(b, r.Lo, i == 0 && !negated, inAlt)
+		if r.Hi != r.Lo {
+			b.WriteByte('-')
+			writeClassRune(b, r.Hi, false, inAlt)
+		}
+	}
+	b.WriteByte(']')
+}
+
+func writeClassRune(b *strings.Builder, r rune, first, inAlt bool) {
+	if r == '\\' || r == ']' || r == '-' || (first && (r == '^' || r == '!')) || (inAlt && (r == ',' || r == '{' || r == '}')) {
+		b.WriteByte('\\')
+	}
+	b.WriteRune(r)
+}
+
+// Removes the backslash from any escaped characters.
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
+
+// Walk traverses the syntax tree rooted at `n` in depth-first order, calling
+// `fn` for each node. If `fn` returns false, the children of that node are not
+// visited.
+func Walk(n *Node, fn func(n *Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.Children {
+		Walk(c, fn)
+	}
+}
@@ -1,6 +1,7 @@
 package doublestar
 
 import (
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -57,6 +58,89 @@ func SplitPattern(p string) (base, pattern string) {
 	return
 }
 
+// Split is a base path and pattern, as returned by SplitPatternAll.
+type Split struct {
+	Base    string
+	Pattern string
+}
+
+// SplitPatternAll is like SplitPattern, except that it will also expand any
+// alternatives (`{...}`) that appear before the first `*`, `?`, or `[` into
+// separate base paths. For example, given the pattern:
+//
+//   {src,lib}/pkg/**/*.go
+//
+// SplitPattern would return "." and the unaltered pattern, meaning the whole
+// tree would have to be searched. SplitPatternAll, on the other hand, returns:
+//
+//   []Split{
+//     {Base: "src/pkg", Pattern: "**/*.go"},
+//     {Base: "lib/pkg", Pattern: "**/*.go"},
+//   }
+//
+// Each Split can be used to initialize a narrower os.DirFS() to call Glob().
+// Like SplitPattern, the base paths are still patterns, so any escaped meta
+// characters are still escaped: pass each base path through Unescape() before
+// using it as a path, as in `os.DirFS(Unescape(split.Base))`. Duplicate splits
+// are removed, but the base paths may still overlap (consider
+// `{a,a/b}/*`), so the results of globbing each split may contain duplicates.
+// Alternatives that appear after a `*`, `?`, or `[` are left in the pattern.
+//
+// If the pattern contains no alternatives before the first `*`, `?`, or `[`,
+// SplitPatternAll returns a single Split, equal to the results of
+// SplitPattern.
+//
+// SplitPatternAll assumes the pattern is valid. If it's not, the results are
+// undefined.
+//
+func SplitPatternAll(p string) []Split {
+	alts := expandLeadingAlts(p)
+	splits := make([]Split, 0, len(alts))
+	seen := make(map[Split]bool, len(alts))
+	for _, alt := range alts {
+		base, pattern := SplitPattern(alt)
+		split := Split{base, pattern}
+		if !seen[split] {
+			seen[split] = true
+			splits = append(splits, split)
+		}
+	}
+	return splits
+}
+
+// Expands any alternatives (`{...}`) that appear before the first `*`, `?`, or
+// `[` in the pattern. For example, `{a,b}/c/{d,e*}` expands into `a/c/d`,
+// `a/c/e*`, `b/c/d`, and `b/c/e*`. Assumes `p` has been validated.
+func expandLeadingAlts(p string) []string {
+	openingIdx := indexMeta(p)
+	if openingIdx == -1 || p[openingIdx] != '{' {
+		return []string{p}
+	}
+
+	closingIdx := indexMatchedClosingAlt(p[openingIdx+1:], true)
+	if closingIdx == -1 {
+		return []string{p}
+	}
+	closingIdx += openingIdx + 1
+
+	var expanded []string
+	patIdx := openingIdx + 1
+	for {
+		nextIdx := indexNextAlt(p[patIdx:closingIdx], true)
+		if nextIdx == -1 {
+			nextIdx = closingIdx
+		} else {
+			nextIdx += patIdx
+		}
+
+		expanded = append(expanded, expandLeadingAlts(p[:openingIdx]+p[patIdx:nextIdx]+p[closingIdx+1:])...)
+		if nextIdx == closingIdx {
+			return expanded
+		}
+		patIdx = nextIdx + 1
+	}
+}
+
 // FilepathGlob returns the names of all files matching pattern or nil if there
 // is no matching file. The syntax of pattern is the same as in Match(). The
 // pattern may describe hierarchical names such as usr/*/bin/ed.
@@ -66,7 +150,10 @@ func SplitPattern(p string) (base, pattern string) {
 // reporting that the pattern is malformed.
 //
 // To enable aborting on I/O errors, the WithFailOnIOErrors option can be
-// passed.
+// passed. If the WithCollectIOErrors option is passed, all of the matches are
+// returned along with the collected I/O errors. Paths passed to an
+// ErrorHandler, or recorded in a GlobIOError, are joined with the base path
+// and use the system's path separator.
 //
 // Note: FilepathGlob is a convenience function that is meant as a drop-in
 // replacement for `path/filepath.Glob()` for users who don't need the
@@ -86,9 +173,20 @@ func FilepathGlob(pattern string, opts ...GlobOption) (matches []string, err err
 	pattern = filepath.Clean(pattern)
 	pattern = filepath.ToSlash(pattern)
 	base, f := SplitPattern(pattern)
+	if !ValidatePattern(f) {
+		return nil, ErrBadPattern
+	}
+
+	// the base is part of the pattern, so any escaped meta characters in it
+	// have to be unescaped before it's used as a path
+	base = Unescape(base)
+	g := newGlob(opts...)
+	g.osBase = base
 	fs := os.DirFS(base)
-	if matches, err = Glob(fs, f, opts...); err != nil {
-		return nil, err
+	if matches, err = g.glob(fs, f); err != nil {
+		if _, ok := err.(joinedIOErrors); !ok {
+			return nil, err
+		}
 	}
 	for i := range matches {
 		// use path.Join because we used ToSlash above to ensure our paths are made
@@ -98,6 +196,42 @@ func FilepathGlob(pattern string, opts ...GlobOption) (matches []string, err err
 	return
 }
 
+// FilepathGlobWalk calls the callback function `fn` for every file matching
+// pattern. It is the GlobWalk() equivalent of FilepathGlob(): the pattern is
+// handled exactly like FilepathGlob() and the path passed to `fn` is joined
+// with the base path and uses the system's path separator, just like the
+// results of FilepathGlob(). Otherwise, the behavior is the same as
+// GlobWalk(), including support for returning SkipDir from `fn`.
+//
+// FilepathGlobWalk ignores file system errors such as I/O errors reading
+// directories by default. FilepathGlobWalk may return ErrBadPattern, reporting
+// that the pattern is malformed. To enable aborting on I/O errors, the
+// WithFailOnIOErrors option can be passed.
+//
+// Additionally, if the callback function `fn` returns an error,
+// FilepathGlobWalk will exit immediately and return that error.
+//
+// Note: the returned error doublestar.ErrBadPattern is not equal to
+// filepath.ErrBadPattern.
+//
+func FilepathGlobWalk(pattern string, fn GlobWalkFunc, opts ...GlobOption) error {
+	pattern = filepath.Clean(pattern)
+	pattern = filepath.ToSlash(pattern)
+	base, f := SplitPattern(pattern)
+	if !ValidatePattern(f) {
+		return ErrBadPattern
+	}
+
+	base = Unescape(base)
+	g := newGlob(opts...)
+	g.osBase = base
+	return g.globWalk(os.DirFS(base), f, func(p string, d fs.DirEntry) error {
+		// use path.Join because we used ToSlash above to ensure our paths are made
+		// of forward slashes, no matter what the system uses
+		return fn(filepath.FromSlash(path.Join(base, p)), d)
+	})
+}
+
 // Finds the next comma, but ignores any commas that appear inside nested `{}`.
 // Assumes that each opening bracket has a corresponding closing bracket.
 func indexNextAlt(s string, allowEscaping bool) int {
@@ -118,9 +252,99 @@ func indexNextAlt(s string, allowEscaping bool) int {
 	return -1
 }
 
-var metaReplacer = strings.NewReplacer("\\*", "*", "\\?", "?", "\\[", "[", "\\]", "]", "\\{", "{", "\\}", "}")
+// QuoteMeta returns a pattern that matches the literal string `s`. In other
+// words, every character in `s` that has a special meaning in a pattern (ie,
+// `\*?[]{},`) is escaped with a backslash. QuoteMeta is the inverse of
+// Unescape: Unescape(QuoteMeta(s)) == s for any `s`.
+//
+// This is useful for building patterns from literal paths which may contain
+// meta characters, for example:
+//
+//   pattern := QuoteMeta(userDir) + "/**/*.txt"
+//
+// QuoteMeta assumes the pattern will be used with Match() or Glob(). If the
+// pattern will be used with PathMatch(), use PathQuoteMeta() instead.
+//
+func QuoteMeta(s string) string {
+	return doQuoteMeta(s, '/')
+}
+
+// PathQuoteMeta is like QuoteMeta, except that it returns a pattern suitable
+// for PathMatch(). On systems where the path separator is `'\'`, escaping is
+// disabled, so PathQuoteMeta will instead wrap meta characters in a character
+// class (for example, `*` becomes `[*]`), and leave backslashes alone since
+// they are path separators. Because alternatives (`{...}`) do not take
+// character classes into account, the result should not be used inside of an
+// alternative on these systems if `s` contains `{` or `}`.
+//
+func PathQuoteMeta(s string) string {
+	return doQuoteMeta(s, filepath.Separator)
+}
 
-// Unescapes meta characters (*?[]{})
-func unescapeMeta(pattern string) string {
-	return metaReplacer.Replace(pattern)
+func doQuoteMeta(s string, separator rune) string {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if isQuotableMeta(s[i], separator) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+
+	// escaping with a backslash adds one byte per meta character; wrapping in a
+	// character class adds two
+	buf := make([]byte, 0, len(s)+2*n)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !isQuotableMeta(c, separator) {
+			buf = append(buf, c)
+		} else if separator == '\\' {
+			buf = append(buf, '[', c, ']')
+		} else {
+			buf = append(buf, '\\', c)
+		}
+	}
+	return string(buf)
+}
+
+// Returns true if `c` needs to be quoted by QuoteMeta. If the separator is
+// `\`, escaping is disabled, so neither `\` nor `]` (which can't be put in a
+// character class by itself) can be quoted. Outside of a character class, a
+// `]` is a literal anyway.
+func isQuotableMeta(c byte, separator rune) bool {
+	switch c {
+	case '*', '?', '[', '{', '}', ',':
+		return true
+	case '\\', ']':
+		return separator != '\\'
+	}
+	return false
+}
+
+// Unescape removes the backslashes from any escaped characters in the
+// pattern. In other words, given a pattern without any unescaped meta
+// characters, Unescape returns the literal string that the pattern matches.
+// Unescape is the inverse of QuoteMeta: Unescape(QuoteMeta(s)) == s for any
+// `s`.
+//
+// Unescape assumes the pattern uses `/` as the path separator. A trailing
+// backslash, which would make the pattern invalid, is left alone.
+//
+func Unescape(pattern string) string {
+	idx := strings.IndexByte(pattern, '\\')
+	if idx == -1 {
+		return pattern
+	}
+
+	buf := make([]byte, 0, len(pattern)-1)
+	buf = append(buf, pattern[:idx]...)
+	for i := idx; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			// skip the backslash and keep the next byte, whatever it is
+			i++
+		}
+		buf = append(buf, pattern[i])
+	}
+	return string(buf)
 }
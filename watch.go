@@ -0,0 +1,218 @@
+package doublestar
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// ChangeKind is the kind of a Change reported by Watch().
+type ChangeKind int
+
+const (
+	// Added means that a path started matching the pattern, either because it
+	// was created or because it was renamed.
+	Added ChangeKind = iota + 1
+
+	// Removed means that a path no longer matches the pattern.
+	Removed
+
+	// Modified means that a path still matches the pattern, but its size,
+	// modification time, or mode changed.
+	Modified
+)
+
+var changeKindNames = [...]string{
+	Added:    "Added",
+	Removed:  "Removed",
+	Modified: "Modified",
+}
+
+// String returns the name of the ChangeKind, such as "Added".
+func (k ChangeKind) String() string {
+	if k > 0 && int(k) < len(changeKindNames) {
+		return changeKindNames[k]
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a change to the set of paths matching a pattern. See Watch().
+type Change struct {
+	Path string
+	Kind ChangeKind
+}
+
+// The part of a match's fs.FileInfo that Watch() compares to detect
+// modifications.
+type watchState struct {
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// Watch polls `fsys` every `interval` for changes to the set of paths that
+// match `pattern`, and sends them on the returned channel. The syntax of
+// pattern is the same as in Match(), and `opts` are passed to GlobWalk() on
+// each poll. Watch doesn't depend on any OS-specific notification API, so it
+// works with any fs.FS.
+//
+// Watch globs `fsys` once before it returns, and changes are reported
+// relative to that. On each poll, the matches are compared with the previous
+// poll's by path, and by the size, modification time, and mode from
+// fs.Stat(), and the changes are sent in order by path. A match that can't be
+// stat'd is treated as if it didn't match. If a poll fails (which is only
+// possible if an option like WithFailOnIOErrors is passed), it is skipped, so
+// a temporary error doesn't look like every path was removed. If the first
+// poll fails, the first one that succeeds is used as the baseline instead, so
+// its matches aren't reported as added.
+//
+// To avoid listing the whole tree on every poll, Watch only reads a directory
+// again if its modification time changed since the last poll. Directories
+// with a zero modification time, such as those in a testing/fstest.MapFS, are
+// always read again, as are directories whose modification time is within a
+// couple of seconds of when they were last read, since some file systems only
+// store modification times to the second (or two), so a file added right
+// after the directory was read may not change it. The listing is only used to
+// find the matches: writing to a file doesn't change its directory's
+// modification time, so each match is stat'd again on every poll.
+//
+// The channel is closed once `ctx` is done. It is also closed immediately if
+// `interval` isn't positive, or if the pattern is malformed, so call
+// ValidatePattern() first if the pattern comes from user input. Changes aren't
+// buffered: polling waits until the previous poll's changes have been
+// received.
+//
+func Watch(ctx context.Context, fsys fs.FS, pattern string, interval time.Duration, opts ...GlobOption) <-chan Change {
+	ch := make(chan Change)
+	if interval <= 0 || !ValidatePattern(pattern) {
+		close(ch)
+		return ch
+	}
+
+	wfs := &watchFS{fsys: fsys}
+	state, err := wfs.poll(pattern, opts)
+	haveBaseline := err == nil
+
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next, err := wfs.poll(pattern, opts)
+			if err != nil {
+				continue
+			}
+			if !haveBaseline {
+				state, haveBaseline = next, true
+				continue
+			}
+			for _, c := range diffWatchStates(state, next) {
+				select {
+				case ch <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+			state = next
+		}
+	}()
+	return ch
+}
+
+// watchFS wraps an fs.FS to reuse directory listings from the previous poll
+// if the directory's modification time hasn't changed.
+type watchFS struct {
+	fsys fs.FS
+	prev map[string]watchListing
+	next map[string]watchListing
+}
+
+type watchListing struct {
+	modTime  time.Time
+	listedAt time.Time
+	entries  []fs.DirEntry
+}
+
+// A listing is only reused if the directory's modification time is at least
+// this much older than the time the listing was read. Otherwise, it's "racy":
+// the directory may have changed since without changing its modification
+// time, on file systems that store it with a coarse granularity, such as FAT.
+const watchRacyWindow = 2 * time.Second
+
+// Globs `pattern` and returns the state of each match.
+func (w *watchFS) poll(pattern string, opts []GlobOption) (map[string]watchState, error) {
+	w.next = make(map[string]watchListing)
+	state := make(map[string]watchState)
+	err := GlobWalk(w, pattern, func(p string, d fs.DirEntry) error {
+		// d may come from a cached listing, and some file systems' DirEntry.Info()
+		// is a snapshot from when the directory was read, so stat the match
+		if info, err := fs.Stat(w.fsys, p); err == nil {
+			state[p] = watchState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		if _, ok := err.(joinedIOErrors); !ok {
+			return nil, err
+		}
+	}
+
+	w.prev = w.next
+	return state, nil
+}
+
+func (w *watchFS) Open(name string) (fs.File, error) {
+	return w.fsys.Open(name)
+}
+
+func (w *watchFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(w.fsys, name)
+}
+
+func (w *watchFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, err := fs.Stat(w.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime := info.ModTime()
+	if prev, ok := w.prev[name]; ok && !modTime.IsZero() && prev.modTime.Equal(modTime) && !prev.listedAt.Before(modTime.Add(watchRacyWindow)) {
+		w.next[name] = prev
+		return prev.entries, nil
+	}
+
+	listedAt := time.Now()
+	entries, err := fs.ReadDir(w.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	w.next[name] = watchListing{modTime: modTime, listedAt: listedAt, entries: entries}
+	return entries, nil
+}
+
+// Returns the changes from `prev` to `next`, in order by path.
+func diffWatchStates(prev, next map[string]watchState) (changes []Change) {
+	for p, s := range next {
+		if ps, ok := prev[p]; !ok {
+			changes = append(changes, Change{Path: p, Kind: Added})
+		} else if ps.size != s.size || !ps.modTime.Equal(s.modTime) || ps.mode != s.mode {
+			changes = append(changes, Change{Path: p, Kind: Modified})
+		}
+	}
+	for p := range prev {
+		if _, ok := next[p]; !ok {
+			changes = append(changes, Change{Path: p, Kind: Removed})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return
+}
@@ -0,0 +1,224 @@
+package doublestar
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4/memfs"
+)
+
+// Receives changes from `ch` until `n` have been received, or a second passes,
+// and sorts them by path, since they may be spread over several polls.
+func receiveChanges(t *testing.T, ch <-chan Change, n int) (changes []Change) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for len(changes) < n {
+		select {
+		case c, ok := <-ch:
+			if !ok {
+				n = 0
+				continue
+			}
+			changes = append(changes, c)
+		case <-timeout:
+			return
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return
+}
+
+func TestWatch(t *testing.T) {
+	fsys := memfs.New().
+		File("src/a.go", []byte("a")).
+		File("src/b.go", []byte("b")).
+		File("src/c.txt", []byte("c"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, fsys, "src/*.go", time.Millisecond)
+
+	fsys.File("src/d.go", []byte("d")).
+		File("src/e.txt", []byte("e")).
+		Remove("src/a.go").
+		File("src/b.go", []byte("bb"))
+	expected := []Change{{"src/a.go", Removed}, {"src/b.go", Modified}, {"src/d.go", Added}}
+	if changes := receiveChanges(t, ch, 3); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`src/*.go`) = %v - should be %v", changes, expected)
+	}
+
+	fsys.Touch("src/d.go", time.Unix(1600000000, 0))
+	expected = []Change{{"src/d.go", Modified}}
+	if changes := receiveChanges(t, ch, 1); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`src/*.go`) after Touch = %v - should be %v", changes, expected)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchBadPattern(t *testing.T) {
+	ch := Watch(context.Background(), memfs.New(), "src/[", time.Millisecond)
+	if _, ok := <-ch; ok {
+		t.Errorf("Watch(`src/[`) should close the channel")
+	}
+}
+
+func TestWatchBadInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ch := Watch(context.Background(), memfs.New(), "src/*", interval)
+		if _, ok := <-ch; ok {
+			t.Errorf("Watch(`src/*`, %v) should close the channel", interval)
+		}
+	}
+}
+
+// Counts calls to ReadDir() for each directory.
+type readDirCountingFS struct {
+	*memfs.FS
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *readDirCountingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	c.mu.Lock()
+	c.calls[name]++
+	c.mu.Unlock()
+	return c.FS.ReadDir(name)
+}
+
+func (c *readDirCountingFS) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[name]
+}
+
+func TestWatchPrunesUnchangedDirectories(t *testing.T) {
+	modTime := time.Unix(1600000000, 0)
+	fsys := &readDirCountingFS{
+		FS: memfs.New().
+			File("a/x.go", nil).
+			File("b/y.go", nil).
+			Touch("a", modTime).
+			Touch("b", modTime),
+		calls: make(map[string]int),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, fsys, "*/*.go", time.Millisecond)
+
+	// changing a directory's modification time makes Watch read it again
+	fsys.File("b/z.go", nil).Touch("b", modTime.Add(time.Second))
+	expected := []Change{{"b/z.go", Added}}
+	if changes := receiveChanges(t, ch, 1); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`*/*.go`) = %v - should be %v", changes, expected)
+	}
+
+	// the root has a zero modification time, so it's read on every poll
+	if a, b, root := fsys.count("a"), fsys.count("b"), fsys.count("."); a != 1 || b != 2 || root < 2 {
+		t.Errorf("Watch(`*/*.go`) read a %d times, b %d times, and . %d times - should be 1, 2, and at least 2", a, b, root)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchRacyDirectoryModTime(t *testing.T) {
+	// on file systems that store modification times to the second, a file added
+	// in the same second the directory was read doesn't change its modification
+	// time, so the listing must not be reused
+	fsys := &readDirCountingFS{
+		FS: memfs.New().
+			File("src/a.go", nil).
+			Touch("src", time.Now().Truncate(time.Second)),
+		calls: make(map[string]int),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, fsys, "src/*.go", time.Millisecond)
+
+	fsys.File("src/b.go", nil)
+	expected := []Change{{"src/b.go", Added}}
+	if changes := receiveChanges(t, ch, 1); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`src/*.go`) = %v - should be %v", changes, expected)
+	}
+	if n := fsys.count("src"); n < 2 {
+		t.Errorf("Watch(`src/*.go`) read src %d times - should be at least 2", n)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchModifiedInUnchangedDirectory(t *testing.T) {
+	// writing to a file doesn't change its directory's modification time, so
+	// Watch reuses the listing, but must still notice that the file changed
+	fsys := memfs.New().
+		File("a/x", []byte("1")).
+		Touch("a", time.Unix(1600000000, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, fsys, "a/*", time.Millisecond)
+
+	fsys.File("a/x", []byte("22222"))
+	expected := []Change{{"a/x", Modified}}
+	if changes := receiveChanges(t, ch, 1); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`a/*`) = %v - should be %v", changes, expected)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchBaselineError(t *testing.T) {
+	fsys := &readDirCountingFS{
+		FS: memfs.New().
+			File("src/a.go", nil).
+			File("src/b.go", nil).
+			Error("src", fs.ErrPermission),
+		calls: make(map[string]int),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Watch(ctx, fsys, "src/*.go", time.Millisecond, WithFailOnIOErrors())
+
+	// once the error is gone, wait for two more polls to list src, so that the
+	// first one has finished and become the baseline
+	fsys.Error("src", nil)
+	deadline := time.Now().Add(time.Second)
+	for calls := fsys.count("src"); fsys.count("src") < calls+2 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the matches from the baseline must not be reported as added
+	fsys.File("src/c.go", nil)
+	expected := []Change{{"src/c.go", Added}}
+	if changes := receiveChanges(t, ch, 1); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Watch(`src/*.go`) = %v - should be %v", changes, expected)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestChangeKindString(t *testing.T) {
+	for k, expected := range map[ChangeKind]string{Added: "Added", Removed: "Removed", Modified: "Modified", 0: "ChangeKind(0)"} {
+		if s := k.String(); s != expected {
+			t.Errorf("ChangeKind(%d).String() = %q - should be %q", int(k), s, expected)
+		}
+	}
+}